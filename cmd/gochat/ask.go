@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+	"github.com/saiashirwad/gochat/internal/llm"
+	"github.com/saiashirwad/gochat/internal/storage"
+	"github.com/saiashirwad/gochat/internal/theme"
+)
+
+// renderWidth is the wrap width for replies rendered as Markdown
+const renderWidth = 80
+
+// askOptions controls a non-interactive request
+type askOptions struct {
+	prompt string
+	resume string // ID of a saved chat to continue
+	render bool   // Render the reply as Markdown once it is complete
+	save   bool   // Save a new conversation so it can be continued
+}
+
+// runAsk implements `gochat ask [flags] [prompt]` and returns the exit code
+func runAsk(args []string) int {
+	fs := flag.NewFlagSet("ask", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gochat ask [flags] [prompt]")
+		fmt.Fprintln(fs.Output(), "\nSends the prompt, followed by standard input if it is piped, and prints the reply.")
+		fs.PrintDefaults()
+	}
+	var opts askOptions
+	profile := fs.String("profile", "", "Config `profile` to use instead of default_profile")
+	fs.StringVar(&opts.resume, "resume", "", "ID of a saved chat to continue")
+	fs.BoolVar(&opts.render, "render", false, "Render the reply as Markdown")
+	fs.BoolVar(&opts.save, "save", false, "Save the conversation and print its ID")
+	words, ok := parseFlags(fs, args)
+	if !ok {
+		return 2
+	}
+	opts.prompt = strings.Join(words, " ")
+
+	cfg, ok := loadConfig(*profile)
+	if !ok || !checkConfig(cfg) {
+		return 1
+	}
+	return ask(cfg, opts)
+}
+
+// ask sends one prompt and streams the reply to stdout. It returns the
+// process exit code.
+func ask(cfg *config.Config, opts askOptions) int {
+	prompt, err := readPrompt(opts.prompt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		return 1
+	}
+	if prompt == "" {
+		fmt.Fprintln(os.Stderr, "Error: no prompt given")
+		return 2
+	}
+
+	store := storage.New(cfg.Storage.ChatsDir)
+	session := storage.NewChat(cfg.LLM.Model)
+	if opts.resume != "" {
+		if session, err = store.Load(opts.resume); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading chat: %v\n", err)
+			return 1
+		}
+		opts.save = true
+	}
+	session.Append(chat.NewMessage(chat.RoleUser, prompt))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = llm.WithRetryHook(ctx, func(retry llm.Retry) {
+		fmt.Fprintf(os.Stderr, "%v; retrying in %s (attempt %d of %d)\n",
+			retry.Err, retry.Delay.Round(100*time.Millisecond), retry.Attempt+1, retry.MaxAttempts)
+	})
+
+	var renderer *glamour.TermRenderer
+	if opts.render {
+		if renderer, err = newRenderer(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	reply, err := streamReply(ctx, llm.NewClient(cfg), session, renderer)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return 130
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	session.Append(reply)
+
+	if opts.save {
+		if err := store.Save(session); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving chat: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Saved as %s\n", session.ID)
+	}
+	return 0
+}
+
+// readPrompt joins the prompt with standard input when input is piped
+func readPrompt(prompt string) (string, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return strings.TrimSpace(prompt), nil
+	}
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{strings.TrimSpace(prompt), strings.TrimSpace(string(input))}
+	return strings.TrimSpace(strings.Join(parts, "\n\n")), nil
+}
+
+// newRenderer returns a Markdown renderer in the configured theme
+func newRenderer(cfg *config.Config) (*glamour.TermRenderer, error) {
+	t, err := theme.Load(cfg.UI.Theme, cfg.ThemeDirs())
+	if err != nil {
+		return nil, err
+	}
+	return t.Renderer(renderWidth)
+}
+
+// streamReply requests a reply to the session's active path. Without a
+// renderer the reply is printed as it arrives; with one, once complete.
+func streamReply(ctx context.Context, client *llm.Client, session *storage.Chat, renderer *glamour.TermRenderer) (chat.Message, error) {
+	params := client.Params()
+	if session.Params != nil {
+		params = params.With(*session.Params)
+	}
+
+	started := time.Now()
+	history, truncation, err := client.PrepareHistory(ctx, session.History(), params)
+	if err != nil {
+		return chat.Message{}, err
+	}
+	if truncation.Truncated() {
+		fmt.Fprintf(os.Stderr, "Context window full, %s\n", truncation)
+	}
+
+	chunks, err := client.StreamMessageContext(ctx, history, params)
+	if err != nil {
+		return chat.Message{}, err
+	}
+
+	reply := chat.NewMessage(chat.RoleAssistant, "")
+	reply.Model = client.Model()
+	reply.Profile = client.Profile()
+	if !params.IsZero() {
+		reply.Params = &params
+	}
+
+	var raw, reasoning strings.Builder
+	var timing chat.Timing
+	printer := answerPrinter{out: os.Stdout}
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return chat.Message{}, chunk.Err
+		}
+		if chunk.Usage != nil {
+			reply.Usage = chunk.Usage
+		}
+		if timing.FirstToken == 0 && (chunk.Content != "" || chunk.Reasoning != "") {
+			timing.FirstToken = time.Since(started)
+		}
+		raw.WriteString(chunk.Content)
+		reasoning.WriteString(chunk.Reasoning)
+		if renderer == nil {
+			printer.update(raw.String(), chunk.Reasoning != "")
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return chat.Message{}, err
+	}
+
+	thought, answer := chat.ExtractReasoning(raw.String())
+	reply.Content = answer
+	reply.Reasoning = strings.TrimSpace(strings.Join([]string{reasoning.String(), thought}, "\n\n"))
+	reply.Timestamp = time.Now()
+	timing.Total = time.Since(started)
+	reply.Timing = &timing
+
+	if renderer == nil {
+		printer.finish(answer)
+		return reply, nil
+	}
+	out, err := renderer.Render(answer)
+	if err != nil {
+		return chat.Message{}, err
+	}
+	fmt.Print(out)
+	return reply, nil
+}
+
+// heldReplyLimit is how much of a reply without a blank line is held
+// back in case it is reasoning closed by a bare </think>
+const heldReplyLimit = 200
+
+// answerPrinter prints the answer of a streamed reply as it arrives,
+// leaving out reasoning. Text followed by a closing </think> with no
+// opening tag turns out to be reasoning (see chat.ExtractReasoning), so
+// the start of a reply is held back while it could still be that: until
+// it has a blank line or reaches heldReplyLimit, unless a tag arrives or
+// the API sends its reasoning separately first.
+type answerPrinter struct {
+	out      io.Writer
+	printed  string // Answer printed so far
+	resolved bool   // Whether printing has started
+}
+
+// update prints any new answer in raw, the reply so far. separate is
+// whether the latest chunk carried reasoning outside the content.
+func (p *answerPrinter) update(raw string, separate bool) {
+	if !p.resolved {
+		p.resolved = separate || strings.Contains(raw, "<think>") || strings.Contains(raw, "</think>") ||
+			strings.Contains(raw, "\n\n") || len(raw) >= heldReplyLimit
+		if !p.resolved {
+			return
+		}
+	}
+
+	// Hold back anything that may still turn out to open a <think> block
+	_, answer := chat.ExtractReasoning(raw)
+	answer = answer[:len(answer)-partialTag(answer)]
+	if strings.HasPrefix(answer, p.printed) {
+		fmt.Fprint(p.out, answer[len(p.printed):])
+		p.printed = answer
+	}
+}
+
+// finish prints the rest of the complete answer. If a late </think>
+// showed that what was printed was reasoning, the answer is printed again
+// in full after it.
+func (p *answerPrinter) finish(answer string) {
+	if !strings.HasPrefix(answer, p.printed) {
+		fmt.Fprintln(p.out)
+		p.printed = ""
+	}
+	fmt.Fprintln(p.out, answer[len(p.printed):])
+}
+
+// partialTag returns the length of the longest suffix of s that is a
+// proper prefix of the <think> tag
+func partialTag(s string) int {
+	const tag = "<think>"
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
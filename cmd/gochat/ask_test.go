@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+func TestAnswerPrinter(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		separate bool   // Reasoning arrives outside the content
+		early    string // Printed before the reply is complete
+		late     bool   // Reasoning was printed before its </think> came
+	}{
+		{
+			name:   "plain reply",
+			chunks: []string{"The answer is 42.\n\n", "It always", " was."},
+			early:  "The answer is 42.\n\nIt always was.",
+		},
+		{
+			name:   "long paragraph",
+			chunks: []string{strings.Repeat("word ", 40), "and more"},
+			early:  strings.Repeat("word ", 40) + "and more",
+		},
+		{
+			name:   "short reply",
+			chunks: []string{"The answer", " is 42"},
+		},
+		{
+			name:   "think block",
+			chunks: []string{"<thi", "nk>Let me see</th", "ink>The answer", " is 42"},
+			early:  "The answer is 42",
+		},
+		{
+			name:   "bare closing tag",
+			chunks: []string{"Let me see", " about that</thi", "nk>The answer", " is 42"},
+			early:  "The answer is 42",
+		},
+		{
+			name:     "separate reasoning",
+			chunks:   []string{"The answer", " is 42"},
+			separate: true,
+			early:    "The answer is 42",
+		},
+		{
+			name:   "bare closing tag after a blank line",
+			chunks: []string{"Let me see.\n\nHmm", "</think>The answer"},
+			early:  "Let me see.\n\nHmm",
+			late:   true,
+		},
+		{
+			name:   "tag split at the end",
+			chunks: []string{"<think>hmm</think>The answer is 42", "<"},
+			early:  "The answer is 42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			p := answerPrinter{out: &out}
+			raw := ""
+			for _, chunk := range tt.chunks {
+				raw += chunk
+				p.update(raw, tt.separate)
+			}
+			if got := out.String(); got != tt.early {
+				t.Errorf("printed while streaming %q, want %q", got, tt.early)
+			}
+
+			_, answer := chat.ExtractReasoning(raw)
+			p.finish(answer)
+			want := answer + "\n"
+			if tt.late {
+				want = tt.early + "\n" + want
+			}
+			if got := out.String(); got != want {
+				t.Errorf("printed %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/keyring"
+	"golang.org/x/term"
+)
+
+// runAuth implements `gochat auth set|remove`, which manage API keys in
+// the system keyring
+func runAuth(args []string) int {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gochat auth set|remove [-profile name | -provider name]")
+		fmt.Fprintln(fs.Output(), "\nStores the API key in the system keyring. set reads the key from the terminal or standard input.")
+		fs.PrintDefaults()
+	}
+	profile := fs.String("profile", "", "Manage the key of config `profile`")
+	provider := fs.String("provider", "", "Manage the key of `provider` (default from the config)")
+	words, ok := parseFlags(fs, args)
+	if !ok {
+		return 2
+	}
+	if len(words) != 1 || (*profile != "" && *provider != "") {
+		fs.Usage()
+		return 2
+	}
+
+	account := strings.ToLower(*provider)
+	if account == "" {
+		cfg, ok := loadConfig(*profile)
+		if !ok {
+			return 1
+		}
+		account = cfg.KeyringAccount()
+	}
+
+	switch words[0] {
+	case "set":
+		key, err := readKey(account)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading key: %v\n", err)
+			return 1
+		}
+		if key == "" {
+			fmt.Fprintln(os.Stderr, "Error: empty key")
+			return 1
+		}
+		if err := keyring.Set(account, key); err != nil {
+			fmt.Fprintf(os.Stderr, "Error storing key: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Stored API key for %s\n", account)
+	case "remove":
+		if err := keyring.Delete(account); err != nil {
+			if errors.Is(err, keyring.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "No key stored for %s\n", account)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error removing key: %v\n", err)
+			}
+			return 1
+		}
+		fmt.Printf("Removed API key for %s\n", account)
+	default:
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
+
+// readKey reads a key without echoing it when standard input is a
+// terminal, or the first line of piped input otherwise
+func readKey(account string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "API key for %s: ", account)
+		key, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(key)), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
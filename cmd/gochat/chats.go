@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/saiashirwad/gochat/internal/storage"
+)
+
+// openStore loads the configuration and opens the chat store
+func openStore() (*storage.Store, bool) {
+	cfg, ok := loadConfig("")
+	if !ok {
+		return nil, false
+	}
+	return storage.New(cfg.Storage.ChatsDir), true
+}
+
+// runList implements `gochat list`
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	if _, ok := parseFlags(fs, args); !ok {
+		return 2
+	}
+	store, ok := openStore()
+	if !ok {
+		return 1
+	}
+
+	chats, err := store.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing chats: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUPDATED\tMODEL\tTITLE")
+	for _, c := range chats {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.ID, c.UpdatedAt.Format("2006-01-02 15:04"), c.Model, c.DisplayTitle())
+	}
+	w.Flush()
+	return 0
+}
+
+// runShow implements `gochat show ID`
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	render := fs.Bool("render", false, "Render the chat as Markdown")
+	ids, ok := parseFlags(fs, args)
+	if !ok {
+		return 2
+	}
+	if len(ids) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: gochat show [-render] ID")
+		return 2
+	}
+	cfg, ok := loadConfig("")
+	if !ok {
+		return 1
+	}
+
+	saved, err := storage.New(cfg.Storage.ChatsDir).Load(ids[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading chat: %v\n", err)
+		return 1
+	}
+
+	out := storage.ExportMarkdown(saved)
+	if *render {
+		renderer, err := newRenderer(cfg)
+		if err == nil {
+			out, err = renderer.RenderBytes(out)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering chat: %v\n", err)
+			return 1
+		}
+	}
+	os.Stdout.Write(out)
+	return 0
+}
+
+// runExport implements `gochat export ID`
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "Output `format`: md, json or html (default from -o, else md)")
+	output := fs.String("o", "", "Write to `path` instead of standard output")
+	ids, ok := parseFlags(fs, args)
+	if !ok {
+		return 2
+	}
+	if len(ids) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: gochat export [-format md|json|html] [-o path] ID")
+		return 2
+	}
+	cfg, ok := loadConfig("")
+	if !ok {
+		return 1
+	}
+
+	saved, err := storage.New(cfg.Storage.ChatsDir).Load(ids[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading chat: %v\n", err)
+		return 1
+	}
+
+	if *format == "" {
+		*format = storage.FormatFromPath(*output)
+	}
+	data, err := storage.Export(saved, *format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting chat: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing export: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runRemove implements `gochat rm ID...`
+func runRemove(args []string) int {
+	fs := flag.NewFlagSet("rm", flag.ContinueOnError)
+	ids, ok := parseFlags(fs, args)
+	if !ok {
+		return 2
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: gochat rm ID...")
+		return 2
+	}
+	store, ok := openStore()
+	if !ok {
+		return 1
+	}
+
+	status := 0
+	for _, id := range ids {
+		if err := store.Delete(id); err != nil {
+			fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", id, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("Deleted %s\n", id)
+	}
+	return status
+}
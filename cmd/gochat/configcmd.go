@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements `gochat config show|path|validate`
+func runConfig(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: gochat config show|path|validate")
+		return 2
+	}
+
+	cfg, ok := loadConfig("")
+	if !ok {
+		return 1
+	}
+
+	switch args[0] {
+	case "show":
+		out, err := yaml.Marshal(cfg.Settings())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding config: %v\n", err)
+			return 1
+		}
+		os.Stdout.Write(out)
+	case "path":
+		if cfg.Path() == "" {
+			fmt.Fprintln(os.Stderr, "No config file found; using defaults and environment variables")
+			return 1
+		}
+		fmt.Println(cfg.Path())
+	case "validate":
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		path := cfg.Path()
+		if path == "" {
+			path = "configuration"
+		}
+		fmt.Printf("%s: OK\n", path)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config command %q\n", args[0])
+		return 2
+	}
+	return 0
+}
@@ -1,31 +1,170 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/saiashirwad/gochat/internal/config"
+	"github.com/saiashirwad/gochat/internal/storage"
 	"github.com/saiashirwad/gochat/internal/ui"
 )
 
+// command is a gochat subcommand
+type command struct {
+	name    string
+	args    string // Usage hint for arguments
+	summary string
+	run     func(args []string) int
+}
+
+// commands lists the subcommands in the order help shows them
+var commands []command
+
+func init() {
+	commands = []command{
+		{"chat", "[-profile name] [-resume ID]", "Start the chat interface (the default)", runChat},
+		{"ask", "[-profile name] [-resume ID] [-render] [-save] [prompt]", "Print the reply to a prompt and standard input", runAsk},
+		{"list", "", "List saved chats, most recent first", runList},
+		{"show", "[-render] ID", "Print a saved chat", runShow},
+		{"export", "[-format md|json|html] [-o path] ID", "Export a saved chat", runExport},
+		{"rm", "ID...", "Delete saved chats", runRemove},
+		{"config", "show|path|validate", "Inspect the configuration", runConfig},
+		{"auth", "set|remove [-profile name | -provider name]", "Manage API keys in the system keyring", runAuth},
+		{"help", "", "Show this help", runHelp},
+	}
+}
+
 func main() {
-	// Load configuration
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				os.Exit(cmd.run(args[1:]))
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+		printUsage()
+		os.Exit(2)
+	}
+	os.Exit(runChat(args))
+}
+
+// printUsage lists the subcommands on stderr
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: gochat [command] [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		usage := strings.TrimSpace(cmd.name + " " + cmd.args)
+		fmt.Fprintf(os.Stderr, "  %-62s %s\n", usage, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun gochat <command> -h for the flags of a command.")
+}
+
+// runHelp implements `gochat help`
+func runHelp([]string) int {
+	printUsage()
+	return 0
+}
+
+// parseFlags parses flags that may come before or after the positional
+// arguments, which are returned
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, bool) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, false
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, true
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// loadConfig loads the configuration and switches to the named profile,
+// if any, reporting failure on stderr
+func loadConfig(profile string) (*config.Config, bool) {
 	cfg, err := config.Load()
+	if err == nil && profile != "" {
+		err = cfg.UseProfile(profile)
+	}
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return nil, false
+	}
+	return cfg, true
+}
+
+// checkConfig validates the configuration before a command that talks to
+// the model, listing every problem on stderr
+func checkConfig(cfg *config.Config) bool {
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return true
+}
+
+// runChat implements `gochat chat`, which is also what runs without a
+// command. -p sends a single prompt instead, like `gochat ask`.
+func runChat(args []string) int {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gochat [chat] [flags]")
+		fs.PrintDefaults()
+	}
+	resume := fs.String("resume", "", "ID of a saved chat to continue")
+	profile := fs.String("profile", "", "Config `profile` to use instead of default_profile")
+	prompt := fs.String("p", "", "Print the reply to `prompt` without starting the interface")
+	render := fs.Bool("render", false, "With -p, render the reply as Markdown")
+	save := fs.Bool("save", false, "With -p, save the conversation and print its ID")
+	if _, ok := parseFlags(fs, args); !ok {
+		return 2
+	}
+
+	// Load configuration
+	cfg, ok := loadConfig(*profile)
+	if !ok || !checkConfig(cfg) {
+		return 1
+	}
+
+	if *prompt != "" {
+		return ask(cfg, askOptions{
+			prompt: *prompt,
+			resume: *resume,
+			render: *render,
+			save:   *save,
+		})
+	}
+
+	app := ui.NewAppModel(cfg)
+	defer app.Close()
+
+	// Reopen a saved conversation if requested
+	if *resume != "" {
+		saved, err := storage.New(cfg.Storage.ChatsDir).Load(*resume)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading chat: %v\n", err)
+			return 1
+		}
+		app.LoadChat(saved)
 	}
 
 	// Create and start the Bubble Tea program
 	p := tea.NewProgram(
-		ui.NewAppModel(cfg),
+		app,
 		tea.WithAltScreen(),       // Use alternate screen buffer
 		tea.WithMouseCellMotion(), // Enable mouse support
 	)
 
 	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running program: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,238 @@
+package chat
+
+// Conversation is a tree of messages. Every message points at its parent,
+// so edits and regenerations become sibling branches instead of replacing
+// history. The active path runs from the root to Current and is what gets
+// shown and sent to the LLM.
+type Conversation struct {
+	// Messages holds every message of every branch in creation order
+	Messages []*Message `json:"messages"`
+
+	// Current is the ID of the last message on the active path
+	Current string `json:"current,omitempty"`
+
+	index map[string]*Message
+}
+
+// NewConversation creates an empty conversation
+func NewConversation() *Conversation {
+	return &Conversation{index: make(map[string]*Message)}
+}
+
+// Normalize repairs a conversation read from disk: messages saved before
+// the tree model existed are given IDs and chained in order, reasoning
+// left inline in <think> tags is moved to its own field, and an unset or
+// unknown Current falls back to the last message
+func (c *Conversation) Normalize() {
+	c.index = make(map[string]*Message, len(c.Messages))
+
+	var prev *Message
+	for _, msg := range c.Messages {
+		if msg.ID == "" {
+			msg.ID = NewID()
+			if prev != nil {
+				msg.ParentID = prev.ID
+			}
+		}
+		if msg.Role == RoleAssistant && msg.Reasoning == "" {
+			msg.Reasoning, msg.Content = ExtractReasoning(msg.Content)
+		}
+		c.index[msg.ID] = msg
+		prev = msg
+	}
+
+	if _, ok := c.index[c.Current]; !ok {
+		c.Current = ""
+		if prev != nil {
+			c.Current = prev.ID
+		}
+	}
+}
+
+// lookup returns the index, building it if needed
+func (c *Conversation) lookup() map[string]*Message {
+	if c.index == nil {
+		c.Normalize()
+	}
+	return c.index
+}
+
+// Get returns the message with the given ID, or nil
+func (c *Conversation) Get(id string) *Message {
+	return c.lookup()[id]
+}
+
+// Append adds msg after the current message and makes it current
+func (c *Conversation) Append(msg Message) *Message {
+	return c.AddChild(c.Current, msg)
+}
+
+// AddChild adds msg as a reply to parentID, alongside any existing
+// replies, and makes it current. An empty parentID adds a new root.
+func (c *Conversation) AddChild(parentID string, msg Message) *Message {
+	if msg.ID == "" {
+		msg.ID = NewID()
+	}
+	msg.ParentID = parentID
+
+	stored := &msg
+	c.Messages = append(c.Messages, stored)
+	c.lookup()[stored.ID] = stored
+	c.Current = stored.ID
+	return stored
+}
+
+// Prepend adds msg as the new root of every branch
+func (c *Conversation) Prepend(msg Message) *Message {
+	roots := c.Children("")
+	current := c.Current
+
+	stored := c.AddChild("", msg)
+	for _, root := range roots {
+		root.ParentID = stored.ID
+	}
+	if current != "" {
+		c.Current = current
+	}
+	return stored
+}
+
+// ActivePath returns the messages from the root to the current message
+func (c *Conversation) ActivePath() []Message {
+	var path []Message
+	for msg := c.Get(c.Current); msg != nil; msg = c.Get(msg.ParentID) {
+		path = append(path, *msg)
+	}
+
+	// Reverse into root-first order
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// History returns the active path as it is sent to the model, leaving
+// out display-only messages, replies that were cancelled before any
+// content arrived and replies cut off by an error
+func (c *Conversation) History() []Message {
+	path := c.ActivePath()
+	history := make([]Message, 0, len(path))
+	seenUser := false
+	for _, msg := range path {
+		if msg.Local() || msg.Failed || (msg.Cancelled && msg.Content == "") {
+			continue
+		}
+		// Chats saved before banners were marked local open with one;
+		// nothing the assistant says before the user speaks is a reply
+		if msg.Role == RoleAssistant && !seenUser {
+			continue
+		}
+		seenUser = seenUser || msg.Role == RoleUser
+		history = append(history, msg)
+	}
+	return history
+}
+
+// Children returns the replies to a message in creation order. An empty
+// id returns the root messages.
+func (c *Conversation) Children(id string) []*Message {
+	var children []*Message
+	for _, msg := range c.Messages {
+		if msg.ParentID == id {
+			children = append(children, msg)
+		}
+	}
+	return children
+}
+
+// Siblings returns the alternatives to a message, itself included, in
+// creation order
+func (c *Conversation) Siblings(id string) []*Message {
+	msg := c.Get(id)
+	if msg == nil {
+		return nil
+	}
+	return c.Children(msg.ParentID)
+}
+
+// Select makes the branch through id active, following the most recent
+// reply at each step down to a leaf
+func (c *Conversation) Select(id string) {
+	if c.Get(id) == nil {
+		return
+	}
+	for {
+		children := c.Children(id)
+		if len(children) == 0 {
+			break
+		}
+		id = children[len(children)-1].ID
+	}
+	c.Current = id
+}
+
+// Rewind makes the parent of id current, so the next message appended
+// becomes a sibling of id
+func (c *Conversation) Rewind(id string) {
+	if msg := c.Get(id); msg != nil {
+		c.Current = msg.ParentID
+	}
+}
+
+// Remove deletes a single message. Its replies are re-attached to its
+// parent so the rest of the conversation survives.
+func (c *Conversation) Remove(id string) {
+	msg := c.Get(id)
+	if msg == nil {
+		return
+	}
+	for _, child := range c.Children(id) {
+		child.ParentID = msg.ParentID
+	}
+	if c.Current == id {
+		c.Current = msg.ParentID
+	}
+	c.drop(map[string]bool{id: true})
+}
+
+// Prune deletes a message together with everything that follows it
+func (c *Conversation) Prune(id string) {
+	msg := c.Get(id)
+	if msg == nil {
+		return
+	}
+
+	removed := map[string]bool{id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, other := range c.Messages {
+			if !removed[other.ID] && removed[other.ParentID] {
+				removed[other.ID] = true
+				changed = true
+			}
+		}
+	}
+
+	if removed[c.Current] {
+		c.Current = msg.ParentID
+	}
+	c.drop(removed)
+}
+
+// drop removes the given messages from storage
+func (c *Conversation) drop(removed map[string]bool) {
+	kept := c.Messages[:0]
+	for _, msg := range c.Messages {
+		if removed[msg.ID] {
+			delete(c.index, msg.ID)
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	c.Messages = kept
+}
+
+// Len returns the number of messages across all branches
+func (c *Conversation) Len() int {
+	return len(c.Messages)
+}
@@ -1,6 +1,12 @@
 package chat
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
 
 // Role represents the role of a message sender (user or assistant)
 type Role string
@@ -13,16 +19,71 @@ const (
 
 // Message represents a chat message
 type Message struct {
-	Role      Role      `json:"role"`
-	Content   string    `json:"content"`
-	Timestamp time.Time `json:"timestamp"`
+	ID        string         `json:"id"`
+	ParentID  string         `json:"parent_id,omitempty"` // Empty for the first message of a conversation
+	Role      Role           `json:"role"`
+	Content   string         `json:"content"`
+	Reasoning string         `json:"reasoning,omitempty"` // Model's thinking; never sent back to it
+	Timestamp time.Time      `json:"timestamp"`
+	Model     string         `json:"model,omitempty"`   // Model that produced an assistant message
+	Profile   string         `json:"profile,omitempty"` // Config profile that produced it, if any
+	Usage     *Usage         `json:"usage,omitempty"`
+	Timing    *Timing        `json:"timing,omitempty"` // How long an assistant message took to arrive
+	Params    *Params        `json:"params,omitempty"` // Sampling parameters an assistant message was requested with
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Cancelled bool           `json:"cancelled,omitempty"` // Reply was aborted before it finished
+	Failed    bool           `json:"failed,omitempty"`    // Reply was cut off by an error
+}
+
+// MetaLocal marks a message that is only shown to the user, such as the
+// welcome banner, and never sent to the model
+const MetaLocal = "local"
+
+// Local reports whether the message is for display only
+func (m Message) Local() bool {
+	local, _ := m.Metadata[MetaLocal].(bool)
+	return local
+}
+
+// SetMeta records a metadata value on the message
+func (m *Message) SetMeta(key string, value any) {
+	if m.Metadata == nil {
+		m.Metadata = make(map[string]any)
+	}
+	m.Metadata[key] = value
+}
+
+// Usage records the tokens a request consumed, as reported by the API
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Timing records how long a reply took, from sending the request
+type Timing struct {
+	FirstToken time.Duration `json:"first_token"` // Until the first content arrived
+	Total      time.Duration `json:"total"`       // Until the reply was complete
 }
 
 // NewMessage creates a new message
 func NewMessage(role Role, content string) Message {
 	return Message{
+		ID:        NewID(),
 		Role:      role,
 		Content:   content,
 		Timestamp: time.Now(),
 	}
 }
+
+// idFallback keeps IDs unique if the system random source fails
+var idFallback atomic.Uint64
+
+// NewID returns a random message ID
+func NewID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(idFallback.Add(1), 36)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Response formats accepted by Params.ResponseFormat
+const (
+	FormatText = "text"
+	FormatJSON = "json_object"
+)
+
+// Params are the sampling parameters sent with a request. Unset fields
+// (zero, nil or empty) leave the provider's default in place, so a set of
+// overrides can be layered on top of the configured defaults with With.
+type Params struct {
+	MaxTokens        int      `mapstructure:"max_tokens" json:"max_tokens,omitempty"`
+	Temperature      *float64 `mapstructure:"temperature" json:"temperature,omitempty"`
+	TopP             *float64 `mapstructure:"top_p" json:"top_p,omitempty"`
+	Stop             []string `mapstructure:"stop" json:"stop,omitempty"`
+	PresencePenalty  *float64 `mapstructure:"presence_penalty" json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `mapstructure:"frequency_penalty" json:"frequency_penalty,omitempty"`
+	Seed             *int     `mapstructure:"seed" json:"seed,omitempty"`
+	ResponseFormat   string   `mapstructure:"response_format" json:"response_format,omitempty"` // text or json_object
+}
+
+// ParamNames lists the parameter names understood by Set, in display order
+var ParamNames = []string{
+	"max_tokens",
+	"temperature",
+	"top_p",
+	"stop",
+	"presence_penalty",
+	"frequency_penalty",
+	"seed",
+	"response_format",
+}
+
+// With returns p with every parameter set in over replacing its own
+func (p Params) With(over Params) Params {
+	if over.MaxTokens > 0 {
+		p.MaxTokens = over.MaxTokens
+	}
+	if over.Temperature != nil {
+		p.Temperature = over.Temperature
+	}
+	if over.TopP != nil {
+		p.TopP = over.TopP
+	}
+	if over.Stop != nil {
+		p.Stop = over.Stop
+	}
+	if over.PresencePenalty != nil {
+		p.PresencePenalty = over.PresencePenalty
+	}
+	if over.FrequencyPenalty != nil {
+		p.FrequencyPenalty = over.FrequencyPenalty
+	}
+	if over.Seed != nil {
+		p.Seed = over.Seed
+	}
+	if over.ResponseFormat != "" {
+		p.ResponseFormat = over.ResponseFormat
+	}
+	return p
+}
+
+// IsZero reports whether no parameter is set
+func (p Params) IsZero() bool {
+	return p.MaxTokens == 0 && p.Temperature == nil && p.TopP == nil &&
+		p.Stop == nil && p.PresencePenalty == nil && p.FrequencyPenalty == nil &&
+		p.Seed == nil && p.ResponseFormat == ""
+}
+
+// Set parses value and assigns it to the named parameter. An empty value
+// unsets it. Stop sequences are separated by commas.
+func (p *Params) Set(name, value string) error {
+	value = strings.TrimSpace(value)
+	switch name {
+	case "max_tokens":
+		if value == "" {
+			p.MaxTokens = 0
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("max_tokens must be a positive integer")
+		}
+		p.MaxTokens = n
+	case "temperature":
+		return setFloat(&p.Temperature, name, value)
+	case "top_p":
+		return setFloat(&p.TopP, name, value)
+	case "presence_penalty":
+		return setFloat(&p.PresencePenalty, name, value)
+	case "frequency_penalty":
+		return setFloat(&p.FrequencyPenalty, name, value)
+	case "stop":
+		p.Stop = nil
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				p.Stop = append(p.Stop, s)
+			}
+		}
+	case "seed":
+		if value == "" {
+			p.Seed = nil
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("seed must be an integer")
+		}
+		p.Seed = &n
+	case "response_format":
+		if value != "" && value != FormatText && value != FormatJSON {
+			return fmt.Errorf("response_format must be %s or %s", FormatText, FormatJSON)
+		}
+		p.ResponseFormat = value
+	default:
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	return nil
+}
+
+// floatRanges holds the accepted range of each numeric parameter
+var floatRanges = map[string][2]float64{
+	"temperature":       {0, 2},
+	"top_p":             {0, 1},
+	"presence_penalty":  {-2, 2},
+	"frequency_penalty": {-2, 2},
+}
+
+// setFloat parses value into *dst, checking it lies within the
+// parameter's range
+func setFloat(dst **float64, name, value string) error {
+	if value == "" {
+		*dst = nil
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || checkRange(name, f) != nil {
+		return rangeError(name)
+	}
+	*dst = &f
+	return nil
+}
+
+// checkRange reports whether f is out of range for the named parameter
+func checkRange(name string, f float64) error {
+	r := floatRanges[name]
+	if f < r[0] || f > r[1] {
+		return rangeError(name)
+	}
+	return nil
+}
+
+func rangeError(name string) error {
+	r := floatRanges[name]
+	return fmt.Errorf("%s must be a number between %g and %g", name, r[0], r[1])
+}
+
+// Problems lists every parameter that is out of range
+func (p Params) Problems() []error {
+	var problems []error
+	if p.MaxTokens < 0 {
+		problems = append(problems, fmt.Errorf("max_tokens must not be negative"))
+	}
+	floats := []struct {
+		name  string
+		value *float64
+	}{
+		{"temperature", p.Temperature},
+		{"top_p", p.TopP},
+		{"presence_penalty", p.PresencePenalty},
+		{"frequency_penalty", p.FrequencyPenalty},
+	}
+	for _, f := range floats {
+		if f.value != nil {
+			if err := checkRange(f.name, *f.value); err != nil {
+				problems = append(problems, err)
+			}
+		}
+	}
+	if p.ResponseFormat != "" && p.ResponseFormat != FormatText && p.ResponseFormat != FormatJSON {
+		problems = append(problems, fmt.Errorf("response_format must be %s or %s", FormatText, FormatJSON))
+	}
+	return problems
+}
+
+// String lists the set parameters as name=value pairs
+func (p Params) String() string {
+	var parts []string
+	add := func(name, value string) {
+		parts = append(parts, name+"="+value)
+	}
+	if p.MaxTokens > 0 {
+		add("max_tokens", strconv.Itoa(p.MaxTokens))
+	}
+	if p.Temperature != nil {
+		add("temperature", formatFloat(*p.Temperature))
+	}
+	if p.TopP != nil {
+		add("top_p", formatFloat(*p.TopP))
+	}
+	if p.Stop != nil {
+		add("stop", strconv.Quote(strings.Join(p.Stop, ",")))
+	}
+	if p.PresencePenalty != nil {
+		add("presence_penalty", formatFloat(*p.PresencePenalty))
+	}
+	if p.FrequencyPenalty != nil {
+		add("frequency_penalty", formatFloat(*p.FrequencyPenalty))
+	}
+	if p.Seed != nil {
+		add("seed", strconv.Itoa(*p.Seed))
+	}
+	if p.ResponseFormat != "" {
+		add("response_format", p.ResponseFormat)
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
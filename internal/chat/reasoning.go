@@ -0,0 +1,41 @@
+package chat
+
+import "strings"
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// ExtractReasoning separates <think>…</think> blocks from a reply. An
+// unclosed block, as seen mid-stream, runs to the end of the content. A
+// closing tag without an opening one, which some models emit, ends
+// reasoning that started at the beginning of the reply.
+func ExtractReasoning(content string) (reasoning, answer string) {
+	if !strings.Contains(content, thinkOpen) {
+		if before, after, ok := strings.Cut(content, thinkClose); ok {
+			return strings.TrimSpace(before), strings.TrimSpace(after)
+		}
+		return "", content
+	}
+
+	var thoughts []string
+	var rest strings.Builder
+	for {
+		before, after, ok := strings.Cut(content, thinkOpen)
+		rest.WriteString(before)
+		if !ok {
+			break
+		}
+		thought, remaining, closed := strings.Cut(after, thinkClose)
+		if t := strings.TrimSpace(thought); t != "" {
+			thoughts = append(thoughts, t)
+		}
+		if !closed {
+			break
+		}
+		content = remaining
+	}
+
+	return strings.Join(thoughts, "\n\n"), strings.TrimSpace(rest.String())
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/keyring"
+)
+
+// KeyringAccount returns the keyring entry that `gochat auth set` writes
+// for the current settings: the active profile's, or else the provider's
+func (c *Config) KeyringAccount() string {
+	return c.keyringAccounts()[0]
+}
+
+// keyringAccounts returns the keyring entries that may hold the API key,
+// most specific first
+func (c *Config) keyringAccounts() []string {
+	provider := strings.ToLower(c.LLM.Provider)
+	if provider == "" {
+		provider = "openai"
+	}
+	if c.profile != "" {
+		return []string{"profile:" + c.profile, provider}
+	}
+	return []string{provider}
+}
+
+// ResolveAPIKey fills in the API key when the config does not set one,
+// first by running api_key_cmd and then from the system keyring. Having
+// no key at all is not an error, since local servers do not need one.
+func (c *Config) ResolveAPIKey() error {
+	if c.LLM.APIKey != "" {
+		return nil
+	}
+
+	if c.LLM.APIKeyCmd != "" {
+		key, err := runKeyCommand(c.LLM.APIKeyCmd)
+		if err != nil {
+			return err
+		}
+		c.LLM.APIKey = key
+		return nil
+	}
+
+	for _, account := range c.keyringAccounts() {
+		key, err := keyring.Get(account)
+		switch {
+		case err == nil:
+			c.LLM.APIKey = key
+			return nil
+		case errors.Is(err, keyring.ErrNotFound), errors.Is(err, keyring.ErrUnavailable):
+			// Try the next entry
+		default:
+			return fmt.Errorf("reading API key from keyring: %w", err)
+		}
+	}
+
+	// Nothing stored; requests go out without a key
+	return nil
+}
+
+// runKeyCommand runs api_key_cmd through the shell and returns the first
+// line it prints
+func runKeyCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		// Do not echo the command's output, which may hold the key
+		return "", fmt.Errorf("api_key_cmd failed: %w", err)
+	}
+	key, _, _ := strings.Cut(string(out), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("api_key_cmd printed nothing")
+	}
+	return key, nil
+}
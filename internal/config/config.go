@@ -1,35 +1,133 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/mitchellh/mapstructure"
+	"github.com/saiashirwad/gochat/internal/chat"
 	"github.com/spf13/viper"
 )
 
 // Config holds the application configuration
 type Config struct {
-	LLM struct {
-		Provider  string `mapstructure:"provider"`
-		Model     string `mapstructure:"model"`
-		APIKey    string `mapstructure:"api_key"`
-		Endpoint  string `mapstructure:"endpoint"`
-		MaxTokens int    `mapstructure:"max_tokens"`
-	} `mapstructure:"llm"`
+	LLM LLMConfig `mapstructure:"llm"`
 
 	UI struct {
-		Theme         string `mapstructure:"theme"`
-		MaxWidth      int    `mapstructure:"max_width"`
-		ShowTimestamp bool   `mapstructure:"show_timestamp"`
+		Theme    string `mapstructure:"theme"`     // Built-in or user theme; empty follows the terminal
+		MaxWidth int    `mapstructure:"max_width"` // Widest a message is drawn
+		Align    string `mapstructure:"align"`     // left or center, when the window is wider
+
+		// Show the time, model, latency and token usage in message
+		// headers, with times as relative or absolute
+		ShowTimestamp   bool   `mapstructure:"show_timestamp"`
+		TimestampFormat string `mapstructure:"timestamp_format"`
+
+		InputMaxRows int `mapstructure:"input_max_rows"`
 	} `mapstructure:"ui"`
 
 	Storage struct {
 		ChatsDir string `mapstructure:"chats_dir"`
 	} `mapstructure:"storage"`
+
+	// Keybinding overrides, from an action in KeyActions to the keys that
+	// trigger it
+	Keys map[string][]string `mapstructure:"keys"`
+
+	// Named LLM settings to switch between, and the one used at startup
+	Profiles       map[string]Profile `mapstructure:"profiles"`
+	DefaultProfile string             `mapstructure:"default_profile"`
+
+	path     string         // Config file that was read, if any
+	settings map[string]any // Effective settings as loaded
+	keys     []string       // Keys set in the file, for spotting typos
+	profile  string         // Active profile, empty for none
+	base     LLMConfig      // LLM settings before any profile was applied
+
+	// Settings that could not be decoded, by key, left at their zero
+	// value for Validate to report
+	decodeErrors map[string]string
 }
 
-// Load reads the configuration from a file and environment variables
+// LLMConfig holds the settings for talking to the model
+type LLMConfig struct {
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+	Endpoint string `mapstructure:"endpoint"`
+
+	// The API key, if not set here or in GOCHAT_LLM_API_KEY, comes from
+	// the output of APIKeyCmd or else the system keyring
+	APIKey    string `mapstructure:"api_key"`
+	APIKeyCmd string `mapstructure:"api_key_cmd"`
+
+	// Resilience: how long to wait for a response to start, and how
+	// often to retry rate limits, server and network errors
+	Timeout    time.Duration `mapstructure:"timeout"`
+	MaxRetries int           `mapstructure:"max_retries"`
+
+	// Default sampling parameters (max_tokens, temperature, ...),
+	// which conversations can override
+	chat.Params `mapstructure:",squash"`
+
+	// Context window management
+	ContextWindow   int    `mapstructure:"context_window"`   // Tokens; 0 looks the model up
+	ContextStrategy string `mapstructure:"context_strategy"` // drop, trim or summarize
+}
+
+// Path returns the config file that was read, or "" if none was found
+func (c *Config) Path() string {
+	return c.path
+}
+
+// ThemeDirs returns the directories searched for user themes: themes/
+// next to the config file, then ~/.config/gochat/themes
+func (c *Config) ThemeDirs() []string {
+	var dirs []string
+	if c.path != "" {
+		dirs = append(dirs, filepath.Join(filepath.Dir(c.path), "themes"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dir := filepath.Join(home, ".config", "gochat", "themes")
+		if len(dirs) == 0 || dirs[0] != dir {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Settings returns the effective settings from the file, environment and
+// defaults as nested maps, with API keys redacted
+func (c *Config) Settings() map[string]any {
+	return redact(c.settings)
+}
+
+// redact copies settings, hiding the value of every api_key
+func redact(settings map[string]any) map[string]any {
+	out := make(map[string]any, len(settings))
+	for k, v := range settings {
+		switch v := v.(type) {
+		case map[string]any:
+			out[k] = redact(v)
+		case string:
+			if k == "api_key" && v != "" {
+				out[k] = "<redacted>"
+			} else {
+				out[k] = v
+			}
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Load reads the configuration from a file and environment variables.
+// Settings of the wrong type and an unknown default_profile do not stop
+// it; they are left unset for Validate to report with everything else.
 func Load() (*Config, error) {
 	v := viper.New()
 
@@ -37,8 +135,14 @@ func Load() (*Config, error) {
 	v.SetDefault("llm.provider", "openai")
 	v.SetDefault("llm.model", "gpt-3.5-turbo")
 	v.SetDefault("llm.max_tokens", 2000)
+	v.SetDefault("llm.context_strategy", "drop")
+	v.SetDefault("llm.timeout", 2*time.Minute)
+	v.SetDefault("llm.max_retries", 3)
 	v.SetDefault("ui.max_width", 100)
+	v.SetDefault("ui.align", "left")
 	v.SetDefault("ui.show_timestamp", true)
+	v.SetDefault("ui.timestamp_format", "relative")
+	v.SetDefault("ui.input_max_rows", 6)
 	v.SetDefault("storage.chats_dir", "chats")
 
 	// Config file settings
@@ -60,6 +164,8 @@ func Load() (*Config, error) {
 		// Config file not found, using defaults and env vars
 	}
 
+	keys := v.AllKeys()
+
 	// Special handling for API key from environment
 	if key := os.Getenv("GOCHAT_LLM_API_KEY"); key != "" {
 		v.Set("llm.api_key", key)
@@ -81,8 +187,39 @@ func Load() (*Config, error) {
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unable to decode config: %w", err)
+		var decodeErr *mapstructure.Error
+		if !errors.As(err, &decodeErr) {
+			return nil, fmt.Errorf("unable to decode config: %w", err)
+		}
+		cfg.decodeErrors = decodeProblems(decodeErr.Errors)
+	}
+	cfg.path = v.ConfigFileUsed()
+	cfg.settings = v.AllSettings()
+	cfg.keys = keys
+	cfg.base = cfg.LLM
+
+	// An unknown default profile leaves the llm section in use
+	if cfg.DefaultProfile != "" {
+		_ = cfg.UseProfile(cfg.DefaultProfile)
 	}
 
 	return &cfg, nil
 }
+
+// decodeProblems turns the messages of a decoding error into problems by
+// setting key. The key is the first quoted name in a message.
+func decodeProblems(messages []string) map[string]string {
+	problems := make(map[string]string, len(messages))
+	for _, msg := range messages {
+		key := "config"
+		if _, rest, ok := strings.Cut(msg, "'"); ok {
+			if name, _, ok := strings.Cut(rest, "'"); ok && name != "" {
+				key = strings.ToLower(name)
+				msg = strings.TrimPrefix(msg, "'"+name+"' ")
+				msg = strings.Replace(msg, " '"+name+"'", "", 1)
+			}
+		}
+		problems[key] = msg
+	}
+	return problems
+}
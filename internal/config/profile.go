@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+// Profile is a named set of LLM settings. Fields left empty keep the
+// values from the llm section.
+type Profile struct {
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Keys of their own, which otherwise come from the keyring entry
+	// named after the profile
+	APIKey    string `mapstructure:"api_key"`
+	APIKeyCmd string `mapstructure:"api_key_cmd"`
+
+	// Sampling parameters layered over the llm section's
+	chat.Params `mapstructure:",squash"`
+}
+
+// Profile returns the name of the active profile, or "" if none is
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// ProfileNames returns the configured profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile replaces the LLM settings with the llm section overlaid by
+// the named profile. An empty name goes back to the llm section alone.
+// Anything changed at runtime, such as the model, is reset.
+func (c *Config) UseProfile(name string) error {
+	// Viper lowercases keys, so names are matched case-insensitively
+	name = strings.ToLower(name)
+	if name == "" {
+		c.LLM = c.base
+		c.profile = ""
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	llm := c.base
+	if profile.Provider != "" {
+		llm.Provider = profile.Provider
+		// A different provider needs its own endpoint and key
+		llm.Endpoint = ""
+		llm.APIKey = ""
+		llm.APIKeyCmd = ""
+	}
+	if profile.Model != "" {
+		llm.Model = profile.Model
+	}
+	if profile.APIKey != "" || profile.APIKeyCmd != "" {
+		llm.APIKey = profile.APIKey
+		llm.APIKeyCmd = profile.APIKeyCmd
+	}
+	if profile.Endpoint != "" {
+		llm.Endpoint = profile.Endpoint
+	}
+	llm.Params = llm.Params.With(profile.Params)
+
+	c.LLM = llm
+	c.profile = name
+	return nil
+}
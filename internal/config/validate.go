@@ -0,0 +1,323 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/keyring"
+	"github.com/saiashirwad/gochat/internal/theme"
+)
+
+// providerRules says what each provider needs beyond a model. Keep in
+// step with llm.NewProvider.
+var providerRules = map[string]struct {
+	needsKey      bool
+	needsEndpoint bool // No sensible default endpoint
+}{
+	"openai":     {needsKey: true},
+	"groq":       {needsKey: true, needsEndpoint: true},
+	"openrouter": {needsKey: true, needsEndpoint: true},
+	"together":   {needsKey: true, needsEndpoint: true},
+	"deepseek":   {needsKey: true, needsEndpoint: true},
+	"mistral":    {needsKey: true, needsEndpoint: true},
+	"lmstudio":   {needsEndpoint: true},
+	"anthropic":  {needsKey: true},
+	"claude":     {needsKey: true},
+	"ollama":     {},
+	"gemini":     {needsKey: true},
+	"google":     {needsKey: true},
+}
+
+// contextStrategies are the accepted values of llm.context_strategy
+var contextStrategies = []string{"drop", "trim", "summarize"}
+
+// Accepted values of ui.align and ui.timestamp_format
+var (
+	alignments       = []string{"left", "center"}
+	timestampFormats = []string{"relative", "absolute"}
+)
+
+// KeyActions are the actions that can be rebound under keys. Keep in step
+// with the key maps in the ui package.
+var KeyActions = []string{
+	// Input
+	"send", "newline", "editor", "complete", "previous",
+
+	// Chat view
+	"page_up", "page_down", "half_up", "half_down", "up", "down", "top", "bottom",
+	"cancel", "retry", "regenerate", "edit", "delete", "prev_branch", "next_branch",
+	"toggle_reasoning", "toggle_all_reasoning", "copy", "copy_text", "copy_code",
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Path     string // Config file, empty if none was read
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	source := e.Path
+	if source == "" {
+		source = "configuration"
+	}
+	noun := "problems"
+	if len(e.Problems) == 1 {
+		noun = "problem"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d %s", source, len(e.Problems), noun)
+	for _, p := range e.Problems {
+		b.WriteString("\n  - " + p)
+	}
+	return b.String()
+}
+
+// Validate checks the configuration as a whole and returns a
+// *ValidationError listing every problem, or nil if there are none
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(key, format string, args ...any) {
+		// A setting that could not be decoded is reported once, as such
+		if _, failed := c.decodeErrors[key]; !failed {
+			problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+		}
+	}
+
+	keys := make([]string, 0, len(c.decodeErrors))
+	for key := range c.decodeErrors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		problems = append(problems, key+": "+c.decodeErrors[key])
+	}
+	for _, key := range c.unknownKeys() {
+		add(key, "unknown setting")
+	}
+
+	// The llm section on its own, then each profile layered over it
+	base := *c
+	if err := base.UseProfile(""); err == nil {
+		c.validateLLM("llm", &base, add)
+	}
+	for _, name := range c.ProfileNames() {
+		profile := *c
+		if err := profile.UseProfile(name); err == nil {
+			c.validateLLM("profiles."+name, &profile, add)
+		}
+	}
+	if c.DefaultProfile != "" {
+		if _, ok := c.Profiles[strings.ToLower(c.DefaultProfile)]; !ok {
+			add("default_profile", "no profile named %q", c.DefaultProfile)
+		}
+	}
+
+	if _, err := theme.Load(c.UI.Theme, c.ThemeDirs()); err != nil {
+		add("ui.theme", "%v", err)
+	}
+	if c.UI.MaxWidth != 0 && c.UI.MaxWidth < 20 {
+		add("ui.max_width", "must be 0 for no limit or at least 20, got %d", c.UI.MaxWidth)
+	}
+	if !contains(alignments, strings.ToLower(c.UI.Align)) {
+		add("ui.align", "must be one of %s, got %q", strings.Join(alignments, ", "), c.UI.Align)
+	}
+	if !contains(timestampFormats, strings.ToLower(c.UI.TimestampFormat)) {
+		add("ui.timestamp_format", "must be one of %s, got %q", strings.Join(timestampFormats, ", "), c.UI.TimestampFormat)
+	}
+	if c.UI.InputMaxRows < 1 || c.UI.InputMaxRows > 50 {
+		add("ui.input_max_rows", "must be between 1 and 50, got %d", c.UI.InputMaxRows)
+	}
+	if c.Storage.ChatsDir == "" {
+		add("storage.chats_dir", "must be set")
+	}
+
+	actions := make([]string, 0, len(c.Keys))
+	for action := range c.Keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		switch {
+		case !contains(KeyActions, action):
+			add("keys."+action, "unknown action")
+		case len(c.Keys[action]) == 0:
+			add("keys."+action, "must list at least one key")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Path: c.path, Problems: problems}
+}
+
+// validateLLM checks the LLM settings that cfg ends up with. Problems are
+// reported under prefix, and settings a profile inherits unchanged are
+// only reported for the llm section.
+func (c *Config) validateLLM(prefix string, cfg *Config, add func(key, format string, args ...any)) {
+	llm := cfg.LLM
+	inherited := func(changed bool) bool {
+		return prefix != "llm" && !changed
+	}
+	var profile Profile
+	if prefix != "llm" {
+		profile = c.Profiles[cfg.profile]
+	}
+
+	provider := strings.ToLower(llm.Provider)
+	rules, known := providerRules[provider]
+	if !inherited(profile.Provider != "") && !known {
+		add(prefix+".provider", "unknown provider %q", llm.Provider)
+	}
+	if !inherited(profile.Model != "") && llm.Model == "" {
+		add(prefix+".model", "must be set")
+	}
+
+	if llm.Endpoint != "" && !inherited(profile.Endpoint != "") {
+		if u, err := url.Parse(llm.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(prefix+".endpoint", "%q is not an http(s) URL", llm.Endpoint)
+		}
+	}
+	if rules.needsEndpoint && llm.Endpoint == "" {
+		add(prefix+".endpoint", "must be set for provider %s", provider)
+	}
+	if rules.needsKey && !localEndpoint(llm.Endpoint) && !cfg.hasAPIKey() {
+		add(prefix+".api_key", "no API key for provider %s; store one with `gochat auth set`, or set api_key_cmd or GOCHAT_LLM_API_KEY", provider)
+	}
+
+	params := llm.Params
+	if prefix != "llm" {
+		params = profile.Params
+	}
+	for _, err := range params.Problems() {
+		name, msg, _ := strings.Cut(err.Error(), " ")
+		add(prefix+"."+name, "%s", msg)
+	}
+
+	if prefix != "llm" {
+		return
+	}
+	if llm.Timeout < 0 {
+		add("llm.timeout", "must not be negative")
+	}
+	if llm.MaxRetries < 0 || llm.MaxRetries > 10 {
+		add("llm.max_retries", "must be between 0 and 10, got %d", llm.MaxRetries)
+	}
+	if llm.ContextWindow < 0 {
+		add("llm.context_window", "must not be negative")
+	}
+	if !contains(contextStrategies, strings.ToLower(llm.ContextStrategy)) {
+		add("llm.context_strategy", "must be one of %s, got %q", strings.Join(contextStrategies, ", "), llm.ContextStrategy)
+	}
+}
+
+// hasAPIKey reports whether an API key is available without running
+// api_key_cmd
+func (c *Config) hasAPIKey() bool {
+	if c.LLM.APIKey != "" || c.LLM.APIKeyCmd != "" {
+		return true
+	}
+	for _, account := range c.keyringAccounts() {
+		if _, err := keyring.Get(account); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// localEndpoint reports whether endpoint is a server on this machine,
+// which usually needs no API key
+func localEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// unknownKeys returns the keys read from the config file that no setting
+// uses, which are usually typos
+func (c *Config) unknownKeys() []string {
+	known := make(map[string]bool)
+	collectKeys(reflect.TypeOf(Config{}), "", known)
+
+	var unknown []string
+	for _, key := range c.keys {
+		if !matchKey(known, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// collectKeys adds the setting keys of a struct type, following its
+// mapstructure tags. Map keys are written as *.
+func collectKeys(t reflect.Type, prefix string, keys map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts == "squash" {
+			collectKeys(field.Type, prefix, keys)
+			continue
+		}
+
+		key := prefix + name
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			collectKeys(field.Type, key+".", keys)
+		case field.Type.Kind() == reflect.Map && field.Type.Elem().Kind() == reflect.Struct:
+			collectKeys(field.Type.Elem(), key+".*.", keys)
+		case field.Type.Kind() == reflect.Map:
+			keys[key+".*"] = true
+		default:
+			keys[key] = true
+		}
+	}
+}
+
+// matchKey reports whether key matches one of the known keys
+func matchKey(known map[string]bool, key string) bool {
+	if known[key] {
+		return true
+	}
+	parts := strings.Split(key, ".")
+	for pattern := range known {
+		patternParts := strings.Split(pattern, ".")
+		if len(patternParts) != len(parts) {
+			continue
+		}
+		matched := true
+		for i, p := range patternParts {
+			if p != "*" && p != parts[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay collapses the burst of events a single save produces
+const watchDelay = 200 * time.Millisecond
+
+// Watcher reports changes to a config file
+type Watcher struct {
+	// Changes receives a value after the file has been written, created or
+	// replaced. Closed once the watcher is closed.
+	Changes <-chan struct{}
+
+	watcher *fsnotify.Watcher
+}
+
+// Watch starts watching the config file at path. The directory is watched
+// rather than the file, because editors often save by writing a new file
+// and renaming it over the old one.
+func Watch(path string) (*Watcher, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error watching config: %w", err)
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("error watching config: %w", err)
+	}
+	if err := fw.Add(filepath.Dir(path)); err != nil {
+		fw.Close()
+		return nil, fmt.Errorf("error watching config: %w", err)
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		var pending <-chan time.Time
+		for {
+			select {
+			case event, ok := <-fw.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					pending = time.After(watchDelay)
+				}
+			case _, ok := <-fw.Errors:
+				if !ok {
+					return
+				}
+			case <-pending:
+				pending = nil
+				select {
+				case changes <- struct{}{}:
+				default: // A change is already waiting to be read
+				}
+			}
+		}
+	}()
+
+	return &Watcher{Changes: changes, watcher: fw}, nil
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
@@ -0,0 +1,108 @@
+// Package keyring keeps API keys in the operating system's secret store:
+// the Secret Service (through secret-tool) on Linux and the login
+// keychain (through security) on macOS.
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service names gochat's entries in the secret store
+const service = "gochat"
+
+var (
+	// ErrNotFound is returned when no secret is stored for an account
+	ErrNotFound = errors.New("no key stored")
+
+	// ErrUnavailable is returned when the system has no usable secret
+	// store
+	ErrUnavailable = errors.New("no system keyring available")
+)
+
+// Get returns the secret stored for account
+func Get(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", ErrUnavailable
+	}
+
+	out, err := run(cmd, "")
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(out, "\r\n")
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores secret for account, replacing any previous one
+func Set(account, secret string) error {
+	var cmd *exec.Cmd
+	stdin := ""
+	switch runtime.GOOS {
+	case "darwin":
+		// security only takes the password as an argument; -U updates an
+		// existing entry
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", service, "-a", account, "-w", secret)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+		stdin = secret
+	default:
+		return ErrUnavailable
+	}
+	_, err := run(cmd, stdin)
+	return err
+}
+
+// Delete removes the secret stored for account
+func Delete(account string) error {
+	if _, err := Get(account); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", service, "-a", account)
+	default:
+		cmd = exec.Command("secret-tool", "clear", "service", service, "account", account)
+	}
+	_, err := run(cmd, "")
+	return err
+}
+
+// run executes a secret store tool and maps its failures to errors. The
+// tools exit non-zero when an entry is missing.
+func run(cmd *exec.Cmd, stdin string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return "", ErrUnavailable
+	case errors.As(err, &exitErr):
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && !strings.Contains(msg, "could not be found") {
+			return "", fmt.Errorf("%s: %s", cmd.Args[0], msg)
+		}
+		return "", ErrNotFound
+	case err != nil:
+		return "", err
+	}
+	return stdout.String(), nil
+}
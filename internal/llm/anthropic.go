@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+const (
+	// defaultAnthropicEndpoint is used when no endpoint is configured
+	defaultAnthropicEndpoint = "https://api.anthropic.com/v1/messages"
+
+	// anthropicVersion is the API version sent with every request
+	anthropicVersion = "2023-06-01"
+
+	// defaultAnthropicMaxTokens is used when llm.max_tokens is unset,
+	// since the Messages API requires a limit
+	defaultAnthropicMaxTokens = 1024
+)
+
+// anthropicProvider speaks the Anthropic Messages API
+type anthropicProvider struct {
+	config *config.LLMConfig
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	MaxTokens int                `json:"max_tokens"`
+	Stream    bool               `json:"stream,omitempty"`
+
+	// Optional sampling parameters; the API has no penalties, seed or
+	// response format
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicResponse struct {
+	ID      string                  `json:"id"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Thinking string `json:"thinking"`
+	} `json:"delta"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage *anthropicUsage       `json:"usage"`
+	Error *anthropicErrorDetail `json:"error"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicError struct {
+	Type  string               `json:"type"`
+	Error anthropicErrorDetail `json:"error"`
+}
+
+type anthropicErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// NewRequest builds a Messages API request, moving system messages to the
+// top-level system field
+func (p *anthropicProvider) NewRequest(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Request, error) {
+	system, rest := splitSystem(messages)
+	turns := mergeTurns(rest)
+
+	apiMessages := make([]anthropicMessage, len(turns))
+	for i, msg := range turns {
+		apiMessages[i] = anthropicMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		}
+	}
+
+	maxTokens := params.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+
+	jsonBody, err := json.Marshal(anthropicRequest{
+		Model:     p.config.Model,
+		System:    system,
+		Messages:  apiMessages,
+		MaxTokens: maxTokens,
+		Stream:    stream,
+
+		Temperature:   params.Temperature,
+		TopP:          params.TopP,
+		StopSequences: params.Stop,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	endpoint := endpointOr(p.config, defaultAnthropicEndpoint)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.config.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	return req, nil
+}
+
+// ParseResponse joins the text blocks of the reply
+func (p *anthropicProvider) ParseResponse(body []byte) (string, error) {
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var text bytes.Buffer
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+	return text.String(), nil
+}
+
+// ParseStream forwards text deltas until message_stop
+func (p *anthropicProvider) ParseStream(r io.Reader, emit func(StreamChunk) error) error {
+	// Input tokens arrive at the start, output tokens at the end
+	var inputTokens int
+	return readSSE(r, func(_, data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("error parsing stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			inputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			switch {
+			case event.Delta.Type == "text_delta" && event.Delta.Text != "":
+				return emit(StreamChunk{Content: event.Delta.Text})
+			case event.Delta.Type == "thinking_delta" && event.Delta.Thinking != "":
+				return emit(StreamChunk{Reasoning: event.Delta.Thinking})
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				return emit(StreamChunk{Usage: &chat.Usage{
+					PromptTokens:     inputTokens,
+					CompletionTokens: event.Usage.OutputTokens,
+					TotalTokens:      inputTokens + event.Usage.OutputTokens,
+				}})
+			}
+		case "message_stop":
+			return errStreamDone
+		case "error":
+			if event.Error != nil {
+				return newAPIError(http.StatusOK, event.Error.Message, event.Error.Type, "")
+			}
+			return newAPIError(http.StatusOK, data, "", "")
+		}
+		return nil
+	})
+}
+
+// ParseError decodes an Anthropic error body
+func (p *anthropicProvider) ParseError(statusCode int, body []byte) error {
+	var apiErr anthropicError
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+		return newAPIError(statusCode, apiErr.Error.Message, apiErr.Error.Type, "")
+	}
+	return newAPIError(statusCode, string(body), "", "")
+}
@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+func TestAnthropicRequest(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, `{"id":"msg_1","content":[{"type":"thinking","thinking":"Hmm"},{"type":"text","text":"Hello"},{"type":"text","text":"!"}]}`)
+
+	// The system prompt moves to its own field, the leading assistant
+	// greeting is dropped and consecutive user turns are joined
+	messages := []chat.Message{
+		chat.NewMessage(chat.RoleSystem, "Be brief."),
+		chat.NewMessage(chat.RoleAssistant, "Welcome"),
+		chat.NewMessage(chat.RoleUser, "Hi"),
+		chat.NewMessage(chat.RoleUser, "Anyone there?"),
+	}
+	params := chat.Params{
+		Temperature: floatPtr(0.2),
+		Stop:        []string{"END"},
+
+		// Not supported by the API, so left out
+		PresencePenalty: floatPtr(1),
+		ResponseFormat:  chat.FormatJSON,
+	}
+	reply, err := testClient("anthropic", srv.URL+"/v1/messages").SendMessageContext(context.Background(), messages, params)
+	if err != nil {
+		t.Fatalf("SendMessageContext: %v", err)
+	}
+	if reply != "Hello!" {
+		t.Errorf("reply %q, want %q", reply, "Hello!")
+	}
+
+	if got.method != http.MethodPost || got.path != "/v1/messages" {
+		t.Errorf("request %s %s, want POST /v1/messages", got.method, got.path)
+	}
+	for name, want := range map[string]string{
+		"x-api-key":         "test-key",
+		"anthropic-version": anthropicVersion,
+		"Authorization":     "",
+	} {
+		if value := got.header.Get(name); value != want {
+			t.Errorf("%s %q, want %q", name, value, want)
+		}
+	}
+	want := jsonOf(t, map[string]any{
+		"model":  "test-model",
+		"system": "Be brief.",
+		"messages": []map[string]string{
+			{"role": "user", "content": "Hi\n\nAnyone there?"},
+		},
+		"max_tokens":     defaultAnthropicMaxTokens,
+		"temperature":    0.2,
+		"stop_sequences": []string{"END"},
+	})
+	if !reflect.DeepEqual(jsonOf(t, got.body), want) {
+		t.Errorf("request body\n%v\nwant\n%v", got.body, want)
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	body := `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me think"}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hel"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"lo"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"ignored"}}
+
+`
+	srv, got := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("anthropic", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{MaxTokens: 50})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.err != nil {
+		t.Fatalf("stream error: %v", res.err)
+	}
+	if res.content != "Hello" || res.reasoning != "Let me think" {
+		t.Errorf("content %q, reasoning %q, want %q, %q", res.content, res.reasoning, "Hello", "Let me think")
+	}
+	if want := (chat.Usage{PromptTokens: 12, CompletionTokens: 4, TotalTokens: 16}); res.usage == nil || *res.usage != want {
+		t.Errorf("usage %+v, want %+v", res.usage, want)
+	}
+
+	if got.body["stream"] != true || got.body["max_tokens"] != 50.0 {
+		t.Errorf("stream %v, max_tokens %v, want true, 50", got.body["stream"], got.body["max_tokens"])
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	body := `event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+
+`
+	srv, _ := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("anthropic", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.content != "Hel" {
+		t.Errorf("content %q, want %q", res.content, "Hel")
+	}
+	checkAPIError(t, res.err, http.StatusOK, ErrServer, "Overloaded")
+}
+
+func TestAnthropicError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{
+			name:    "invalid key",
+			status:  http.StatusUnauthorized,
+			body:    `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			kind:    ErrAuth,
+			message: "invalid x-api-key",
+		},
+		{
+			name:    "rate limit",
+			status:  http.StatusTooManyRequests,
+			body:    `{"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your rate limit"}}`,
+			kind:    ErrRateLimited,
+			message: "Number of request tokens has exceeded your rate limit",
+		},
+		{
+			name:    "prompt too long",
+			status:  http.StatusBadRequest,
+			body:    `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+			kind:    ErrContextLength,
+			message: "prompt is too long: 210000 tokens > 200000 maximum",
+		},
+		{
+			name:    "overloaded",
+			status:  529,
+			body:    `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			kind:    ErrServer,
+			message: "Overloaded",
+		},
+		{
+			name:    "invalid request",
+			status:  http.StatusBadRequest,
+			body:    `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: Field required"}}`,
+			message: "max_tokens: Field required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := serve(t, tt.status, tt.body)
+			_, err := testClient("anthropic", srv.URL).SendMessageContext(context.Background(), testMessages(), chat.Params{})
+			checkAPIError(t, err, tt.status, tt.kind, tt.message)
+		})
+	}
+}
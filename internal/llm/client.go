@@ -1,137 +1,164 @@
 package llm
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/saiashirwad/gochat/internal/chat"
 	"github.com/saiashirwad/gochat/internal/config"
 )
 
-// APIError represents an error response from the API
-type APIError struct {
-	Error struct {
-		Message string `json:"message"`
-		Type    string `json:"type"`
-		Code    string `json:"code"`
-	} `json:"error"`
-}
-
-// Client handles communication with the LLM API
+// Client handles communication with the LLM API. It keeps its own copy
+// of the LLM settings, so a client making a request is not affected by
+// later changes to the config; settings change by making a new client.
 type Client struct {
-	config     *config.Config
+	config     config.LLMConfig
+	profile    string // Config profile the settings came from
 	httpClient *http.Client
+	provider   Provider
+	err        error // Set when the configured provider is unusable
+
+	// Context strategy, created on first use
+	strategyOnce sync.Once
+	strategy     ContextStrategy
+	strategyErr  error
 }
 
-// NewClient creates a new LLM client
+// NewClient creates a new LLM client for the configured provider, looking
+// up the API key if the config does not hold one. If the provider is not
+// supported or the key cannot be read, every request fails with that
+// error.
 func NewClient(cfg *config.Config) *Client {
-	return &Client{
-		config:     cfg,
-		httpClient: &http.Client{},
+	// The timeout covers waiting for the response to start, so long
+	// streamed replies are not cut off
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = cfg.LLM.Timeout
+
+	c := &Client{
+		config:     cfg.LLM,
+		profile:    cfg.Profile(),
+		httpClient: &http.Client{Transport: transport},
 	}
+	c.provider, c.err = NewProvider(&c.config)
+	if c.err == nil {
+		// The key is resolved on a copy, leaving cfg as it was read
+		settings := *cfg
+		c.err = settings.ResolveAPIKey()
+		c.config.APIKey = settings.LLM.APIKey
+	}
+	return c
 }
 
-type chatRequest struct {
-	Model    string        `json:"model"`
-	Messages []chatMessage `json:"messages"`
-	Stream   bool          `json:"stream"`
+// WithModel returns a client like c that asks for a different model
+func (c *Client) WithModel(model string) *Client {
+	client := &Client{
+		config:     c.config,
+		profile:    c.profile,
+		httpClient: c.httpClient,
+		err:        c.err,
+	}
+	client.config.Model = model
+	client.provider, _ = NewProvider(&client.config) // Fails only as c did
+	return client
 }
 
-type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// Model returns the configured model
+func (c *Client) Model() string {
+	return c.config.Model
 }
 
-type chatResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Choices []choice `json:"choices"`
+// Profile returns the config profile in use, if any
+func (c *Client) Profile() string {
+	return c.profile
 }
 
-type choice struct {
-	Index   int     `json:"index"`
-	Message message `json:"message"`
+// Params returns the configured default sampling parameters
+func (c *Client) Params() chat.Params {
+	return c.config.Params
 }
 
-type message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// SendMessage sends a message to the LLM with the default parameters and
+// returns the response
+func (c *Client) SendMessage(messages []chat.Message) (string, error) {
+	return c.SendMessageContext(context.Background(), messages, c.Params())
 }
 
-// SendMessage sends a message to the LLM and returns the response
-func (c *Client) SendMessage(messages []chat.Message) (string, error) {
-	// Convert messages to API format
-	apiMessages := make([]chatMessage, len(messages))
-	for i, msg := range messages {
-		apiMessages[i] = chatMessage{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-		}
+// SendMessageContext is like SendMessage but uses the given parameters and
+// aborts the request when ctx is cancelled
+func (c *Client) SendMessageContext(ctx context.Context, messages []chat.Message, params chat.Params) (string, error) {
+	resp, err := c.do(ctx, messages, params, false)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	// Create request body
-	reqBody := chatRequest{
-		Model:    c.config.LLM.Model,
-		Messages: apiMessages,
-		Stream:   false,
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response: %w", err)
 	}
 
-	// Marshal request body
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %w", err)
+	return c.provider.ParseResponse(body)
+}
+
+// do sends a completion request and checks the response status,
+// retrying transport failures, rate limits and server errors with
+// backoff. The caller must close the body of the returned response.
+func (c *Client) do(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
 	}
 
+	attempts := max(c.config.MaxRetries, 0) + 1
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, messages, params, stream)
+		if err == nil || attempt == attempts || !retryable(ctx, err) {
+			return resp, err
+		}
+
+		delay := backoff(attempt, err)
+		if hook := retryHookFrom(ctx); hook != nil {
+			hook(Retry{Attempt: attempt, MaxAttempts: attempts, Delay: delay, Err: err})
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt makes a single round trip for do
+func (c *Client) attempt(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Response, error) {
 	// Create request
-	req, err := http.NewRequest("POST", c.config.LLM.Endpoint, bytes.NewBuffer(jsonBody))
+	req, err := c.provider.NewRequest(ctx, messages, params, stream)
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+		return nil, err
 	}
 
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.LLM.APIKey)
-
 	// Send request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("error sending request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("error reading response: %w", err)
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		// Try to parse error response
-		var apiErr APIError
-		if err := json.Unmarshal(body, &apiErr); err == nil {
-			return "", fmt.Errorf("API error: %s (type: %s, code: %s)",
-				apiErr.Error.Message,
-				apiErr.Error.Type,
-				apiErr.Error.Code)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response: %w", err)
 		}
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
-	var chatResp chatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", fmt.Errorf("error parsing response: %w", err)
-	}
-
-	// Return first choice content
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from LLM")
+		err = c.provider.ParseError(resp.StatusCode, body)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.RetryAfter = parseRetryAfter(resp.StatusCode, resp.Header, time.Now())
+		}
+		return nil, err
 	}
 
-	return chatResp.Choices[0].Message.Content, nil
+	return resp, nil
 }
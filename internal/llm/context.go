@@ -0,0 +1,294 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+const (
+	// defaultResponseReserve is kept free for the reply when
+	// llm.max_tokens is unset
+	defaultResponseReserve = 1024
+
+	// trimmedTokens is how much of an old message the trim strategy keeps
+	trimmedTokens = 200
+
+	// trimMarker replaces the removed part of a trimmed message
+	trimMarker = "\n\n[…trimmed to fit the context window…]"
+
+	// summaryPrefix introduces the summary of dropped turns
+	summaryPrefix = "Summary of the earlier conversation:\n\n"
+)
+
+// Truncation describes how a history was shortened to fit the context
+// budget. The zero value means nothing was changed.
+type Truncation struct {
+	Dropped    int // Messages removed entirely
+	Trimmed    int // Messages shortened
+	Summarized int // Messages replaced by a summary
+	Tokens     int // Estimated tokens of the history that is sent
+	Budget     int // Tokens available for the history
+}
+
+// Truncated reports whether anything was removed or shortened
+func (t Truncation) Truncated() bool {
+	return t.Dropped > 0 || t.Trimmed > 0 || t.Summarized > 0
+}
+
+// String describes the truncation for display
+func (t Truncation) String() string {
+	var parts []string
+	if t.Summarized > 0 {
+		parts = append(parts, fmt.Sprintf("%d summarized", t.Summarized))
+	}
+	if t.Trimmed > 0 {
+		parts = append(parts, fmt.Sprintf("%d trimmed", t.Trimmed))
+	}
+	if t.Dropped > 0 {
+		parts = append(parts, fmt.Sprintf("%d dropped", t.Dropped))
+	}
+	if len(parts) == 0 {
+		return "full history"
+	}
+	return "older messages: " + strings.Join(parts, ", ")
+}
+
+// ContextStrategy shortens a history that does not fit the token budget.
+// Implementations always keep leading system messages and the final
+// message.
+type ContextStrategy interface {
+	Fit(ctx context.Context, model string, messages []chat.Message, budget int) ([]chat.Message, Truncation, error)
+}
+
+// NewContextStrategy returns the strategy selected by llm.context_strategy
+func NewContextStrategy(name string, client *Client) (ContextStrategy, error) {
+	switch strings.ToLower(name) {
+	case "", "drop":
+		return dropStrategy{}, nil
+	case "trim":
+		return trimStrategy{}, nil
+	case "summarize":
+		return &summarizeStrategy{client: client, cache: make(map[string]string)}, nil
+	default:
+		return nil, fmt.Errorf("unknown context strategy %q", name)
+	}
+}
+
+// ContextBudget returns the tokens available for the history: the
+// model's context window minus room for the reply
+func (c *Client) ContextBudget(params chat.Params) int {
+	window := c.config.ContextWindow
+	if window <= 0 {
+		window = ContextWindow(c.config.Model)
+	}
+	reserve := params.MaxTokens
+	if reserve <= 0 {
+		reserve = defaultResponseReserve
+	}
+	if budget := window - reserve; budget > window/4 {
+		return budget
+	}
+	return window / 2
+}
+
+// PrepareHistory fits messages into the context budget left by a request
+// with the given parameters, using the configured strategy
+func (c *Client) PrepareHistory(ctx context.Context, messages []chat.Message, params chat.Params) ([]chat.Message, Truncation, error) {
+	c.strategyOnce.Do(func() {
+		c.strategy, c.strategyErr = NewContextStrategy(c.config.ContextStrategy, c)
+	})
+	if c.strategyErr != nil {
+		return nil, Truncation{}, c.strategyErr
+	}
+
+	model := c.config.Model
+	budget := c.ContextBudget(params)
+	if tokens := HistoryTokens(model, messages); tokens <= budget {
+		return messages, Truncation{Tokens: tokens, Budget: budget}, nil
+	}
+	return c.strategy.Fit(ctx, model, messages, budget)
+}
+
+// splitProtected separates the leading system messages and the final
+// message, which are never removed, from the turns in between
+func splitProtected(messages []chat.Message) (head, middle, tail []chat.Message) {
+	start := 0
+	for start < len(messages) && messages[start].Role == chat.RoleSystem {
+		start++
+	}
+	end := len(messages)
+	if end > start {
+		end--
+	}
+	return messages[:start], messages[start:end], messages[end:]
+}
+
+// join reassembles a history from its parts
+func join(parts ...[]chat.Message) []chat.Message {
+	var out []chat.Message
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// dropStrategy removes the oldest turns until the history fits
+type dropStrategy struct{}
+
+func (dropStrategy) Fit(_ context.Context, model string, messages []chat.Message, budget int) ([]chat.Message, Truncation, error) {
+	head, middle, tail := splitProtected(messages)
+	fixed := HistoryTokens(model, head) + HistoryTokens(model, tail)
+
+	n := dropCount(model, middle, budget-fixed)
+	kept := join(head, middle[n:], tail)
+	return kept, Truncation{
+		Dropped: n,
+		Tokens:  HistoryTokens(model, kept),
+		Budget:  budget,
+	}, nil
+}
+
+// dropCount returns how many of the oldest messages must go for the rest
+// to fit in budget. Whole turns are dropped, so a reply never survives
+// without the message it answered.
+func dropCount(model string, messages []chat.Message, budget int) int {
+	total := HistoryTokens(model, messages)
+	n := 0
+	for n < len(messages) && total > budget {
+		total -= MessageTokens(model, messages[n])
+		n++
+		// Take the rest of the turn along
+		for n < len(messages) && messages[n].Role != chat.RoleUser {
+			total -= MessageTokens(model, messages[n])
+			n++
+		}
+	}
+	return n
+}
+
+// trimStrategy shortens the oldest messages first, and only drops turns
+// if trimming everything is not enough
+type trimStrategy struct{}
+
+func (trimStrategy) Fit(ctx context.Context, model string, messages []chat.Message, budget int) ([]chat.Message, Truncation, error) {
+	head, middle, tail := splitProtected(messages)
+	trimmed := make([]chat.Message, len(middle))
+	copy(trimmed, middle)
+
+	result := Truncation{Budget: budget}
+	total := HistoryTokens(model, messages)
+	for i := range trimmed {
+		if total <= budget {
+			break
+		}
+		before := MessageTokens(model, trimmed[i])
+		if before <= trimmedTokens+messageOverhead {
+			continue
+		}
+		trimmed[i].Content = trimText(trimmed[i].Content, trimmedTokens*4) + trimMarker
+		total -= before - MessageTokens(model, trimmed[i])
+		result.Trimmed++
+	}
+
+	kept := join(head, trimmed, tail)
+	if total > budget {
+		var dropped Truncation
+		kept, dropped, _ = dropStrategy{}.Fit(ctx, model, kept, budget)
+		result.Dropped = dropped.Dropped
+	}
+	result.Tokens = HistoryTokens(model, kept)
+	return kept, result, nil
+}
+
+// trimText keeps roughly the first n bytes of s, cutting at a rune
+// boundary
+func trimText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimSpace(s[:n])
+}
+
+// utf8RuneStart reports whether b can start a UTF-8 sequence
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// summarizeStrategy asks the model to summarize the turns that would be
+// dropped and sends that summary instead. Summaries are cached so the
+// same turns are not summarized on every request.
+type summarizeStrategy struct {
+	client *Client
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func (s *summarizeStrategy) Fit(ctx context.Context, model string, messages []chat.Message, budget int) ([]chat.Message, Truncation, error) {
+	head, middle, tail := splitProtected(messages)
+
+	// Leave room for the summary itself
+	summaryBudget := budget / 8
+	fixed := HistoryTokens(model, head) + HistoryTokens(model, tail) + summaryBudget
+	n := dropCount(model, middle, budget-fixed)
+	if n == 0 {
+		return dropStrategy{}.Fit(ctx, model, messages, budget)
+	}
+
+	summary, err := s.summarize(ctx, middle[:n])
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, Truncation{}, ctx.Err()
+		}
+		// Fall back to dropping rather than failing the request
+		return dropStrategy{}.Fit(ctx, model, messages, budget)
+	}
+
+	summaryMsg := chat.NewMessage(chat.RoleSystem, summaryPrefix+summary)
+	kept := join(head, []chat.Message{summaryMsg}, middle[n:], tail)
+	return kept, Truncation{
+		Summarized: n,
+		Tokens:     HistoryTokens(model, kept),
+		Budget:     budget,
+	}, nil
+}
+
+// summarize returns a summary of messages, reusing a cached one if the
+// same messages were summarized before
+func (s *summarizeStrategy) summarize(ctx context.Context, messages []chat.Message) (string, error) {
+	var key strings.Builder
+	var transcript strings.Builder
+	for _, msg := range messages {
+		key.WriteString(msg.ID + "/")
+		fmt.Fprintf(&transcript, "%s: %s\n\n", msg.Role, msg.Content)
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[key.String()]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	prompt := []chat.Message{
+		chat.NewMessage(chat.RoleSystem, "Summarize the following conversation in a few short paragraphs. Keep names, decisions, facts and open questions; leave out pleasantries."),
+		chat.NewMessage(chat.RoleUser, transcript.String()),
+	}
+	summary, err := s.client.SendMessageContext(ctx, prompt, s.client.Params())
+	if err != nil {
+		return "", err
+	}
+	_, summary = chat.ExtractReasoning(summary)
+
+	s.mu.Lock()
+	s.cache[key.String()] = summary
+	s.mu.Unlock()
+	return summary, nil
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Kinds of API failure. An *APIError wraps one of these when it can be
+// classified, so callers can test for them with errors.Is.
+var (
+	ErrRateLimited   = errors.New("rate limited")
+	ErrAuth          = errors.New("authentication failed")
+	ErrContextLength = errors.New("context length exceeded")
+	ErrServer        = errors.New("server error")
+)
+
+// APIError is an error reported by the LLM API
+type APIError struct {
+	StatusCode int    // HTTP status, 200 for errors sent inside a stream
+	Message    string // Message from the API, or the raw body
+	Type       string // Provider error type or status, if any
+	Code       string // Provider error code, if any
+
+	// How long the API asked us to wait before retrying, zero if it did
+	// not say
+	RetryAfter time.Duration
+
+	kind error
+}
+
+// newAPIError creates an APIError and classifies it from its status and
+// the provider's type, code and message
+func newAPIError(statusCode int, message, typ, code string) *APIError {
+	e := &APIError{
+		StatusCode: statusCode,
+		Message:    strings.TrimSpace(message),
+		Type:       typ,
+		Code:       code,
+	}
+	e.kind = classify(e)
+	return e
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.kind != nil {
+		return fmt.Sprintf("%v: %s", e.kind, msg)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, msg)
+}
+
+// Unwrap returns the kind of failure, if known
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// Retryable reports whether repeating the request may succeed
+func (e *APIError) Retryable() bool {
+	return e.kind == ErrRateLimited || e.kind == ErrServer
+}
+
+// classify works out the kind of an API error. Providers disagree on
+// how to signal each kind, so this checks statuses first and then the
+// well-known types, codes and messages.
+func classify(e *APIError) error {
+	label := strings.ToLower(e.Type + " " + e.Code)
+	msg := strings.ToLower(e.Message)
+
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden,
+		containsAny(label, "authentication", "permission", "invalid_api_key", "unauthenticated"):
+		return ErrAuth
+	case e.StatusCode == http.StatusTooManyRequests,
+		containsAny(label, "rate_limit", "resource_exhausted"):
+		return ErrRateLimited
+	case containsAny(label, "context_length") ||
+		containsAny(msg, "context length", "context window", "maximum context", "prompt is too long", "too many tokens"):
+		return ErrContextLength
+	case e.StatusCode >= 500,
+		containsAny(label, "overloaded", "server_error", "api_error", "unavailable", "internal"):
+		return ErrServer
+	}
+	return nil
+}
+
+// containsAny reports whether s contains any of substrs
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// defaultGeminiEndpoint is the API base used when no endpoint is
+// configured. The model and method are appended to it.
+const defaultGeminiEndpoint = "https://generativelanguage.googleapis.com/v1beta"
+
+// geminiProvider speaks the Gemini generateContent API
+type geminiProvider struct {
+	config *config.LLMConfig
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text    string `json:"text"`
+	Thought bool   `json:"thought,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	Error *geminiErrorDetail `json:"error"`
+}
+
+type geminiError struct {
+	Error geminiErrorDetail `json:"error"`
+}
+
+type geminiErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// NewRequest builds a generateContent or streamGenerateContent request
+func (p *geminiProvider) NewRequest(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Request, error) {
+	system, rest := splitSystem(messages)
+	turns := mergeTurns(rest)
+
+	reqBody := geminiRequest{
+		Contents: make([]geminiContent, len(turns)),
+	}
+	for i, msg := range turns {
+		role := "user"
+		if msg.Role == chat.RoleAssistant {
+			role = "model"
+		}
+		reqBody.Contents[i] = geminiContent{
+			Role:  role,
+			Parts: []geminiPart{{Text: msg.Content}},
+		}
+	}
+	if system != "" {
+		reqBody.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: system}}}
+	}
+	if !params.IsZero() {
+		reqBody.GenerationConfig = &geminiGenerationConfig{
+			MaxOutputTokens:  params.MaxTokens,
+			Temperature:      params.Temperature,
+			TopP:             params.TopP,
+			StopSequences:    params.Stop,
+			PresencePenalty:  params.PresencePenalty,
+			FrequencyPenalty: params.FrequencyPenalty,
+			Seed:             params.Seed,
+		}
+		if params.ResponseFormat == chat.FormatJSON {
+			reqBody.GenerationConfig.ResponseMimeType = "application/json"
+		}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	method := "generateContent"
+	if stream {
+		method = "streamGenerateContent?alt=sse"
+	}
+	base := strings.TrimSuffix(endpointOr(p.config, defaultGeminiEndpoint), "/")
+	endpoint := fmt.Sprintf("%s/models/%s:%s", base, url.PathEscape(p.config.Model), method)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", p.config.APIKey)
+
+	return req, nil
+}
+
+// ParseResponse joins the text parts of the first candidate
+func (p *geminiProvider) ParseResponse(body []byte) (string, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(resp.Candidates) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+	return geminiText(resp.Candidates[0].Content), nil
+}
+
+// ParseStream forwards the text of each streamed candidate
+func (p *geminiProvider) ParseStream(r io.Reader, emit func(StreamChunk) error) error {
+	return readSSE(r, func(_, data string) error {
+		var resp geminiResponse
+		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+			return fmt.Errorf("error parsing stream event: %w", err)
+		}
+		if resp.Error != nil {
+			return newAPIError(http.StatusOK, resp.Error.Message, resp.Error.Status, "")
+		}
+		if len(resp.Candidates) > 0 {
+			for _, part := range resp.Candidates[0].Content.Parts {
+				if part.Text == "" {
+					continue
+				}
+				chunk := StreamChunk{Content: part.Text}
+				if part.Thought {
+					chunk = StreamChunk{Reasoning: part.Text}
+				}
+				if err := emit(chunk); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Every chunk carries running totals; the last one wins
+		if u := resp.UsageMetadata; u != nil {
+			return emit(StreamChunk{Usage: &chat.Usage{
+				PromptTokens:     u.PromptTokenCount,
+				CompletionTokens: u.CandidatesTokenCount,
+				TotalTokens:      u.TotalTokenCount,
+			}})
+		}
+		return nil
+	})
+}
+
+// ParseError decodes a Google API error body
+func (p *geminiProvider) ParseError(statusCode int, body []byte) error {
+	// Errors may arrive as a single object or wrapped in an array
+	var apiErr geminiError
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+		return newAPIError(statusCode, apiErr.Error.Message, apiErr.Error.Status, "")
+	}
+	var apiErrs []geminiError
+	if err := json.Unmarshal(body, &apiErrs); err == nil && len(apiErrs) > 0 && apiErrs[0].Error.Message != "" {
+		return newAPIError(statusCode, apiErrs[0].Error.Message, apiErrs[0].Error.Status, "")
+	}
+	return newAPIError(statusCode, string(body), "", "")
+}
+
+// geminiText joins the text parts of a content block
+func geminiText(content geminiContent) string {
+	var text strings.Builder
+	for _, part := range content.Parts {
+		if !part.Thought {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+func TestGeminiRequest(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hmm","thought":true},{"text":"Hello"},{"text":"!"}]}}]}`)
+
+	messages := []chat.Message{
+		chat.NewMessage(chat.RoleSystem, "Be brief."),
+		chat.NewMessage(chat.RoleUser, "Hi"),
+		chat.NewMessage(chat.RoleAssistant, "Hello"),
+		chat.NewMessage(chat.RoleUser, "How are you?"),
+	}
+	params := chat.Params{
+		MaxTokens:      200,
+		Temperature:    floatPtr(1),
+		ResponseFormat: chat.FormatJSON,
+	}
+	reply, err := testClient("gemini", srv.URL+"/v1beta/").SendMessageContext(context.Background(), messages, params)
+	if err != nil {
+		t.Fatalf("SendMessageContext: %v", err)
+	}
+	if reply != "Hello!" {
+		t.Errorf("reply %q, want %q", reply, "Hello!")
+	}
+
+	if want := "/v1beta/models/test-model:generateContent"; got.method != http.MethodPost || got.path != want {
+		t.Errorf("request %s %s, want POST %s", got.method, got.path, want)
+	}
+	if key := got.header.Get("x-goog-api-key"); key != "test-key" {
+		t.Errorf("x-goog-api-key %q, want %q", key, "test-key")
+	}
+	want := jsonOf(t, map[string]any{
+		"contents": []map[string]any{
+			{"role": "user", "parts": []map[string]string{{"text": "Hi"}}},
+			{"role": "model", "parts": []map[string]string{{"text": "Hello"}}},
+			{"role": "user", "parts": []map[string]string{{"text": "How are you?"}}},
+		},
+		"systemInstruction": map[string]any{
+			"parts": []map[string]string{{"text": "Be brief."}},
+		},
+		"generationConfig": map[string]any{
+			"maxOutputTokens":  200,
+			"temperature":      1,
+			"responseMimeType": "application/json",
+		},
+	})
+	if !reflect.DeepEqual(jsonOf(t, got.body), want) {
+		t.Errorf("request body\n%v\nwant\n%v", got.body, want)
+	}
+}
+
+func TestGeminiStream(t *testing.T) {
+	body := `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Let me think","thought":true}]}}],"usageMetadata":{"promptTokenCount":6,"totalTokenCount":6}}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}],"usageMetadata":{"promptTokenCount":6,"candidatesTokenCount":1,"totalTokenCount":7}}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":6,"candidatesTokenCount":2,"totalTokenCount":8}}
+
+`
+	srv, got := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("gemini", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.err != nil {
+		t.Fatalf("stream error: %v", res.err)
+	}
+	if res.content != "Hello" || res.reasoning != "Let me think" {
+		t.Errorf("content %q, reasoning %q, want %q, %q", res.content, res.reasoning, "Hello", "Let me think")
+	}
+	if want := (chat.Usage{PromptTokens: 6, CompletionTokens: 2, TotalTokens: 8}); res.usage == nil || *res.usage != want {
+		t.Errorf("usage %+v, want %+v", res.usage, want)
+	}
+
+	if want := "/models/test-model:streamGenerateContent?alt=sse"; got.path != want {
+		t.Errorf("path %s, want %s", got.path, want)
+	}
+	if _, ok := got.body["generationConfig"]; ok {
+		t.Errorf("request has generationConfig %v, want none", got.body["generationConfig"])
+	}
+}
+
+func TestGeminiStreamError(t *testing.T) {
+	body := `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}
+
+data: {"error":{"code":503,"message":"The model is overloaded.","status":"UNAVAILABLE"}}
+
+`
+	srv, _ := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("gemini", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.content != "Hel" {
+		t.Errorf("content %q, want %q", res.content, "Hel")
+	}
+	checkAPIError(t, res.err, http.StatusOK, ErrServer, "The model is overloaded.")
+}
+
+func TestGeminiError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{
+			name:    "invalid key",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}`,
+			message: "API key not valid. Please pass a valid API key.",
+		},
+		{
+			name:    "quota",
+			status:  http.StatusTooManyRequests,
+			body:    `[{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}]`,
+			kind:    ErrRateLimited,
+			message: "Resource has been exhausted",
+		},
+		{
+			name:    "permission",
+			status:  http.StatusForbidden,
+			body:    `{"error":{"code":403,"message":"Method doesn't allow unregistered callers","status":"PERMISSION_DENIED"}}`,
+			kind:    ErrAuth,
+			message: "Method doesn't allow unregistered callers",
+		},
+		{
+			name:    "too many tokens",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"code":400,"message":"The input token count (1200000) exceeds the maximum number of tokens allowed (1048576). Too many tokens.","status":"INVALID_ARGUMENT"}}`,
+			kind:    ErrContextLength,
+			message: "The input token count (1200000) exceeds the maximum number of tokens allowed (1048576). Too many tokens.",
+		},
+		{
+			name:    "plain body",
+			status:  http.StatusServiceUnavailable,
+			body:    "Service Unavailable",
+			kind:    ErrServer,
+			message: "Service Unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := serve(t, tt.status, tt.body)
+			_, err := testClient("gemini", srv.URL).SendMessageContext(context.Background(), testMessages(), chat.Params{})
+			checkAPIError(t, err, tt.status, tt.kind, tt.message)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// defaultOllamaEndpoint is used when no endpoint is configured
+const defaultOllamaEndpoint = "http://localhost:11434/api/chat"
+
+// ollamaProvider speaks Ollama's native /api/chat format, which streams
+// newline-delimited JSON rather than server-sent events
+type ollamaProvider struct {
+	config *config.LLMConfig
+}
+
+type ollamaRequest struct {
+	Model    string         `json:"model"`
+	Messages []chatMessage  `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Format   string         `json:"format,omitempty"`
+	Options  *ollamaOptions `json:"options,omitempty"`
+}
+
+// ollamaOptions carries the sampling parameters Ollama calls options
+type ollamaOptions struct {
+	NumPredict       int      `json:"num_predict,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+}
+
+type ollamaResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Role     string `json:"role"`
+		Content  string `json:"content"`
+		Thinking string `json:"thinking"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// NewRequest builds an /api/chat request
+func (p *ollamaProvider) NewRequest(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Request, error) {
+	apiMessages := make([]chatMessage, len(messages))
+	for i, msg := range messages {
+		apiMessages[i] = chatMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		}
+	}
+
+	reqBody := ollamaRequest{
+		Model:    p.config.Model,
+		Messages: apiMessages,
+		Stream:   stream,
+	}
+	if params.ResponseFormat == chat.FormatJSON {
+		reqBody.Format = "json"
+	}
+	params.ResponseFormat = ""
+	if !params.IsZero() {
+		reqBody.Options = &ollamaOptions{
+			NumPredict:       params.MaxTokens,
+			Temperature:      params.Temperature,
+			TopP:             params.TopP,
+			Stop:             params.Stop,
+			PresencePenalty:  params.PresencePenalty,
+			FrequencyPenalty: params.FrequencyPenalty,
+			Seed:             params.Seed,
+		}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	endpoint := endpointOr(p.config, defaultOllamaEndpoint)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		// Only needed when Ollama sits behind an authenticating proxy
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	return req, nil
+}
+
+// ParseResponse returns the reply message content
+func (p *ollamaProvider) ParseResponse(body []byte) (string, error) {
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+	if resp.Error != "" {
+		return "", newAPIError(http.StatusOK, resp.Error, "", "")
+	}
+	return resp.Message.Content, nil
+}
+
+// ParseStream forwards content from each JSON line until done is set
+func (p *ollamaProvider) ParseStream(r io.Reader, emit func(StreamChunk) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp ollamaResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("error parsing stream event: %w", err)
+		}
+		if resp.Error != "" {
+			return newAPIError(http.StatusOK, resp.Error, "", "")
+		}
+		if resp.Message.Content != "" || resp.Message.Thinking != "" {
+			chunk := StreamChunk{Content: resp.Message.Content, Reasoning: resp.Message.Thinking}
+			if err := emit(chunk); err != nil {
+				return err
+			}
+		}
+		if resp.Done {
+			// The final line carries the token counts
+			return emit(StreamChunk{Usage: &chat.Usage{
+				PromptTokens:     resp.PromptEvalCount,
+				CompletionTokens: resp.EvalCount,
+				TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+			}})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %w", err)
+	}
+	return nil
+}
+
+// ParseError decodes an Ollama error body
+func (p *ollamaProvider) ParseError(statusCode int, body []byte) error {
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != "" {
+		return newAPIError(statusCode, resp.Error, "", "")
+	}
+	return newAPIError(statusCode, string(body), "", "")
+}
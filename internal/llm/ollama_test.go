@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+func TestOllamaRequest(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, `{"model":"test-model","message":{"role":"assistant","content":"Hello!"},"done":true}`)
+
+	seed := 3
+	params := chat.Params{
+		MaxTokens:      64,
+		TopP:           floatPtr(0.9),
+		Seed:           &seed,
+		ResponseFormat: chat.FormatJSON,
+	}
+	reply, err := testClient("ollama", srv.URL+"/api/chat").SendMessageContext(context.Background(), testMessages(), params)
+	if err != nil {
+		t.Fatalf("SendMessageContext: %v", err)
+	}
+	if reply != "Hello!" {
+		t.Errorf("reply %q, want %q", reply, "Hello!")
+	}
+
+	if got.method != http.MethodPost || got.path != "/api/chat" {
+		t.Errorf("request %s %s, want POST /api/chat", got.method, got.path)
+	}
+	if auth := got.header.Get("Authorization"); auth != "Bearer test-key" {
+		t.Errorf("Authorization %q, want %q", auth, "Bearer test-key")
+	}
+	want := jsonOf(t, map[string]any{
+		"model": "test-model",
+		"messages": []map[string]string{
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": "Hi"},
+		},
+		"stream": false,
+		"format": "json",
+		"options": map[string]any{
+			"num_predict": 64,
+			"top_p":       0.9,
+			"seed":        3,
+		},
+	})
+	if !reflect.DeepEqual(jsonOf(t, got.body), want) {
+		t.Errorf("request body\n%v\nwant\n%v", got.body, want)
+	}
+}
+
+func TestOllamaRequestDefaults(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, `{"message":{"role":"assistant","content":"Hello!"},"done":true}`)
+
+	// A JSON response format alone sends no options
+	params := chat.Params{ResponseFormat: chat.FormatJSON}
+	if _, err := testClient("ollama", srv.URL).SendMessageContext(context.Background(), testMessages(), params); err != nil {
+		t.Fatalf("SendMessageContext: %v", err)
+	}
+	if _, ok := got.body["options"]; ok {
+		t.Errorf("request has options %v, want none", got.body["options"])
+	}
+	if got.body["format"] != "json" {
+		t.Errorf("format %v, want json", got.body["format"])
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	body := `{"model":"test-model","message":{"role":"assistant","content":"","thinking":"Let me think"},"done":false}
+{"model":"test-model","message":{"role":"assistant","content":"Hel"},"done":false}
+
+{"model":"test-model","message":{"role":"assistant","content":"lo"},"done":false}
+{"model":"test-model","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":9,"eval_count":3}
+{"model":"test-model","message":{"role":"assistant","content":"ignored"},"done":false}
+`
+	srv, got := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("ollama", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.err != nil {
+		t.Fatalf("stream error: %v", res.err)
+	}
+	if res.content != "Hello" || res.reasoning != "Let me think" {
+		t.Errorf("content %q, reasoning %q, want %q, %q", res.content, res.reasoning, "Hello", "Let me think")
+	}
+	if want := (chat.Usage{PromptTokens: 9, CompletionTokens: 3, TotalTokens: 12}); res.usage == nil || *res.usage != want {
+		t.Errorf("usage %+v, want %+v", res.usage, want)
+	}
+
+	if got.body["stream"] != true {
+		t.Errorf("stream %v, want true", got.body["stream"])
+	}
+}
+
+func TestOllamaStreamError(t *testing.T) {
+	body := `{"message":{"role":"assistant","content":"Hel"},"done":false}
+{"error":"an unknown error was encountered while running the model"}
+`
+	srv, _ := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("ollama", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.content != "Hel" {
+		t.Errorf("content %q, want %q", res.content, "Hel")
+	}
+	checkAPIError(t, res.err, http.StatusOK, nil, "an unknown error was encountered while running the model")
+}
+
+func TestOllamaError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{
+			name:    "unknown model",
+			status:  http.StatusNotFound,
+			body:    `{"error":"model \"test-model\" not found, try pulling it first"}`,
+			message: `model "test-model" not found, try pulling it first`,
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			body:    `{"error":"llama runner process has terminated"}`,
+			kind:    ErrServer,
+			message: "llama runner process has terminated",
+		},
+		{
+			name:    "proxy rejects key",
+			status:  http.StatusUnauthorized,
+			body:    "Unauthorized",
+			kind:    ErrAuth,
+			message: "Unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := serve(t, tt.status, tt.body)
+			_, err := testClient("ollama", srv.URL).SendMessageContext(context.Background(), testMessages(), chat.Params{})
+			checkAPIError(t, err, tt.status, tt.kind, tt.message)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// defaultOpenAIEndpoint is used when no endpoint is configured
+const defaultOpenAIEndpoint = "https://api.openai.com/v1/chat/completions"
+
+// openAIError is the error body of an OpenAI-compatible API
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// openAIProvider speaks the OpenAI chat-completions format, which most
+// hosted and local servers (Groq, OpenRouter, LM Studio, ...) also accept
+type openAIProvider struct {
+	config *config.LLMConfig
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+
+	// Sampling parameters, omitted when unset
+	MaxTokens        int             `json:"max_tokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"top_p,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	ResponseFormat   *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponse struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Choices []choice `json:"choices"`
+}
+
+type choice struct {
+	Index   int     `json:"index"`
+	Message message `json:"message"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+
+	// Reasoning models return their thinking in one of these, depending
+	// on the server (DeepSeek, Groq, OpenRouter)
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
+}
+
+type streamResponse struct {
+	ID      string         `json:"id"`
+	Choices []streamChoice `json:"choices"`
+	Usage   *chat.Usage    `json:"usage"`
+
+	// Groq reports usage in its own extension field
+	XGroq *struct {
+		Usage *chat.Usage `json:"usage"`
+	} `json:"x_groq"`
+}
+
+type streamChoice struct {
+	Index        int     `json:"index"`
+	Delta        message `json:"delta"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// NewRequest builds a chat completion request for the given messages
+func (p *openAIProvider) NewRequest(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Request, error) {
+	// Convert messages to API format
+	apiMessages := make([]chatMessage, len(messages))
+	for i, msg := range messages {
+		apiMessages[i] = chatMessage{
+			Role:    string(msg.Role),
+			Content: msg.Content,
+		}
+	}
+
+	// Create request body
+	reqBody := chatRequest{
+		Model:    p.config.Model,
+		Messages: apiMessages,
+		Stream:   stream,
+
+		MaxTokens:        params.MaxTokens,
+		Temperature:      params.Temperature,
+		TopP:             params.TopP,
+		Stop:             params.Stop,
+		PresencePenalty:  params.PresencePenalty,
+		FrequencyPenalty: params.FrequencyPenalty,
+		Seed:             params.Seed,
+	}
+	if params.ResponseFormat != "" {
+		reqBody.ResponseFormat = &responseFormat{Type: params.ResponseFormat}
+	}
+
+	// Marshal request body
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	endpoint := endpointOr(p.config, defaultOpenAIEndpoint)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Add headers
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	return req, nil
+}
+
+// ParseResponse returns the content of the first choice
+func (p *openAIProvider) ParseResponse(body []byte) (string, error) {
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// ParseStream forwards choice deltas until the [DONE] sentinel
+func (p *openAIProvider) ParseStream(r io.Reader, emit func(StreamChunk) error) error {
+	return readSSE(r, func(_, data string) error {
+		if data == "[DONE]" {
+			return errStreamDone
+		}
+
+		var event streamResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("error parsing stream event: %w", err)
+		}
+
+		// Some providers report errors mid-stream as a data event
+		if len(event.Choices) == 0 {
+			var apiErr openAIError
+			if json.Unmarshal([]byte(data), &apiErr) == nil && apiErr.Error.Message != "" {
+				return p.ParseError(http.StatusOK, []byte(data))
+			}
+		}
+
+		for _, ch := range event.Choices {
+			chunk := StreamChunk{
+				Content:   ch.Delta.Content,
+				Reasoning: ch.Delta.ReasoningContent + ch.Delta.Reasoning,
+			}
+			if chunk.Content == "" && chunk.Reasoning == "" {
+				continue
+			}
+			if err := emit(chunk); err != nil {
+				return err
+			}
+		}
+
+		usage := event.Usage
+		if usage == nil && event.XGroq != nil {
+			usage = event.XGroq.Usage
+		}
+		if usage != nil {
+			return emit(StreamChunk{Usage: usage})
+		}
+		return nil
+	})
+}
+
+// ParseError decodes an OpenAI-style error body
+func (p *openAIProvider) ParseError(statusCode int, body []byte) error {
+	// Try to parse error response
+	var apiErr openAIError
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+		return newAPIError(statusCode, apiErr.Error.Message, apiErr.Error.Type, apiErr.Error.Code)
+	}
+	return newAPIError(statusCode, string(body), "", "")
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+func TestOpenAIRequest(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"Hello!"}}]}`)
+
+	seed := 7
+	params := chat.Params{
+		MaxTokens:      100,
+		Temperature:    floatPtr(0.5),
+		Stop:           []string{"END"},
+		Seed:           &seed,
+		ResponseFormat: chat.FormatJSON,
+	}
+	reply, err := testClient("openai", srv.URL+"/v1/chat/completions").SendMessageContext(context.Background(), testMessages(), params)
+	if err != nil {
+		t.Fatalf("SendMessageContext: %v", err)
+	}
+	if reply != "Hello!" {
+		t.Errorf("reply %q, want %q", reply, "Hello!")
+	}
+
+	if got.method != http.MethodPost || got.path != "/v1/chat/completions" {
+		t.Errorf("request %s %s, want POST /v1/chat/completions", got.method, got.path)
+	}
+	if auth := got.header.Get("Authorization"); auth != "Bearer test-key" {
+		t.Errorf("Authorization %q, want %q", auth, "Bearer test-key")
+	}
+	want := jsonOf(t, map[string]any{
+		"model": "test-model",
+		"messages": []map[string]string{
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": "Hi"},
+		},
+		"stream":          false,
+		"max_tokens":      100,
+		"temperature":     0.5,
+		"stop":            []string{"END"},
+		"seed":            7,
+		"response_format": map[string]string{"type": "json_object"},
+	})
+	if !reflect.DeepEqual(jsonOf(t, got.body), want) {
+		t.Errorf("request body\n%v\nwant\n%v", got.body, want)
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	body := `: keep-alive
+
+data: {"choices":[{"delta":{"role":"assistant","reasoning_content":"Thinking"}}]}
+
+data: {"choices":[{"delta":{"content":"Hel"}}]}
+
+data: {"choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}
+
+data: [DONE]
+
+data: {"choices":[{"delta":{"content":"ignored"}}]}
+
+`
+	srv, got := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("openai", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.err != nil {
+		t.Fatalf("stream error: %v", res.err)
+	}
+	if res.content != "Hello" || res.reasoning != "Thinking" {
+		t.Errorf("content %q, reasoning %q, want %q, %q", res.content, res.reasoning, "Hello", "Thinking")
+	}
+	if want := (chat.Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}); res.usage == nil || *res.usage != want {
+		t.Errorf("usage %+v, want %+v", res.usage, want)
+	}
+
+	if got.body["stream"] != true {
+		t.Errorf("stream %v, want true", got.body["stream"])
+	}
+	if accept := got.header.Get("Accept"); accept != "text/event-stream" {
+		t.Errorf("Accept %q, want text/event-stream", accept)
+	}
+}
+
+func TestOpenAIStreamGroqUsage(t *testing.T) {
+	body := `data: {"choices":[{"delta":{"content":"Hi"}}],"x_groq":{"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}}
+
+data: [DONE]
+`
+	srv, _ := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("groq", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if want := (chat.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4}); res.usage == nil || *res.usage != want {
+		t.Errorf("usage %+v, want %+v", res.usage, want)
+	}
+}
+
+func TestOpenAIStreamError(t *testing.T) {
+	body := `data: {"choices":[{"delta":{"content":"Hel"}}]}
+
+data: {"error":{"message":"The server is overloaded","type":"server_error"}}
+
+`
+	srv, _ := serve(t, http.StatusOK, body)
+
+	chunks, err := testClient("openai", srv.URL).StreamMessageContext(context.Background(), testMessages(), chat.Params{})
+	if err != nil {
+		t.Fatalf("StreamMessageContext: %v", err)
+	}
+	res := collect(t, chunks)
+	if res.content != "Hel" {
+		t.Errorf("content %q, want %q", res.content, "Hel")
+	}
+	checkAPIError(t, res.err, http.StatusOK, ErrServer, "The server is overloaded")
+}
+
+func TestOpenAIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{
+			name:    "invalid key",
+			status:  http.StatusUnauthorized,
+			body:    `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			kind:    ErrAuth,
+			message: "Incorrect API key provided",
+		},
+		{
+			name:    "rate limit",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`,
+			kind:    ErrRateLimited,
+			message: "Rate limit reached",
+		},
+		{
+			name:    "context length",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			kind:    ErrContextLength,
+			message: "This model's maximum context length is 8192 tokens",
+		},
+		{
+			name:    "plain body",
+			status:  http.StatusBadGateway,
+			body:    "upstream unavailable\n",
+			kind:    ErrServer,
+			message: "upstream unavailable",
+		},
+		{
+			name:    "unknown model",
+			status:  http.StatusNotFound,
+			body:    `{"error":{"message":"The model does not exist","type":"invalid_request_error","code":"model_not_found"}}`,
+			message: "The model does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := serve(t, tt.status, tt.body)
+			_, err := testClient("openai", srv.URL).SendMessageContext(context.Background(), testMessages(), chat.Params{})
+			checkAPIError(t, err, tt.status, tt.kind, tt.message)
+		})
+	}
+}
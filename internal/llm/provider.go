@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// Provider adapts the client to one LLM API's wire format. The client
+// owns the HTTP round trip; providers only build requests and decode
+// responses.
+type Provider interface {
+	// NewRequest builds the HTTP request for a completion. Sampling
+	// parameters the API does not support are left out.
+	NewRequest(ctx context.Context, messages []chat.Message, params chat.Params, stream bool) (*http.Request, error)
+
+	// ParseResponse extracts the reply from a non-streaming response body
+	ParseResponse(body []byte) (string, error)
+
+	// ParseStream reads a streaming response body and passes each piece of
+	// content, and any token usage report, to emit. It stops early if emit
+	// returns an error.
+	ParseStream(r io.Reader, emit func(StreamChunk) error) error
+
+	// ParseError turns an unsuccessful response into an error
+	ParseError(statusCode int, body []byte) error
+}
+
+// NewProvider returns the provider selected by llm.provider. It keeps cfg,
+// which must not change while requests are made.
+func NewProvider(cfg *config.LLMConfig) (Provider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "openai", "groq", "openrouter", "together", "deepseek", "mistral", "lmstudio":
+		return &openAIProvider{config: cfg}, nil
+	case "anthropic", "claude":
+		return &anthropicProvider{config: cfg}, nil
+	case "ollama":
+		return &ollamaProvider{config: cfg}, nil
+	case "gemini", "google":
+		return &geminiProvider{config: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", cfg.Provider)
+	}
+}
+
+// endpointOr returns the configured endpoint, or fallback if none is set
+func endpointOr(cfg *config.LLMConfig, fallback string) string {
+	if cfg.Endpoint != "" {
+		return cfg.Endpoint
+	}
+	return fallback
+}
+
+// splitSystem separates system messages from the conversation for APIs
+// that take the system prompt as a separate field
+func splitSystem(messages []chat.Message) (string, []chat.Message) {
+	var system []string
+	rest := make([]chat.Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == chat.RoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	return strings.Join(system, "\n\n"), rest
+}
+
+// mergeTurns prepares messages for APIs that require strictly alternating
+// turns starting with the user: leading assistant messages are dropped
+// and consecutive messages from the same role are joined
+func mergeTurns(messages []chat.Message) []chat.Message {
+	var merged []chat.Message
+	for _, msg := range messages {
+		if len(merged) == 0 && msg.Role != chat.RoleUser {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Role == msg.Role {
+			merged[n-1].Content += "\n\n" + msg.Content
+			continue
+		}
+		merged = append(merged, msg)
+	}
+	return merged
+}
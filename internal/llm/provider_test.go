@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// testClient returns a client for provider that sends its requests to
+// endpoint, with a key set and no retries
+func testClient(provider, endpoint string) *Client {
+	cfg := &config.Config{}
+	cfg.LLM.Provider = provider
+	cfg.LLM.Endpoint = endpoint
+	cfg.LLM.Model = "test-model"
+	cfg.LLM.APIKey = "test-key"
+	return NewClient(cfg)
+}
+
+// capturedRequest is what a test server received
+type capturedRequest struct {
+	method string
+	path   string // With the query
+	header http.Header
+	body   map[string]any
+}
+
+// serve starts a server that records the request it gets and answers
+// with status and body
+func serve(t *testing.T, status int, body string) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	got := &capturedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.path = r.URL.RequestURI()
+		got.header = r.Header.Clone()
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &got.body); err != nil {
+			t.Errorf("request body is not JSON: %v\n%s", err, raw)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+// jsonOf returns v encoded and decoded again, for comparing with a
+// decoded request body
+func jsonOf(t *testing.T, v any) any {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// streamResult is everything a stream sent
+type streamResult struct {
+	content   string
+	reasoning string
+	usage     *chat.Usage
+	err       error
+}
+
+// collect reads a stream to its end
+func collect(t *testing.T, chunks <-chan StreamChunk) streamResult {
+	t.Helper()
+	var res streamResult
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return res
+			}
+			res.content += chunk.Content
+			res.reasoning += chunk.Reasoning
+			if chunk.Usage != nil {
+				res.usage = chunk.Usage
+			}
+			if chunk.Err != nil {
+				res.err = chunk.Err
+			}
+		case <-timeout:
+			t.Fatal("stream did not finish")
+		}
+	}
+}
+
+// testMessages is a conversation with a system prompt
+func testMessages() []chat.Message {
+	return []chat.Message{
+		chat.NewMessage(chat.RoleSystem, "Be brief."),
+		chat.NewMessage(chat.RoleUser, "Hi"),
+	}
+}
+
+// floatPtr returns a pointer to f
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestStreamCancel(t *testing.T) {
+	// The first piece of a reply in each provider's stream format
+	tests := []struct {
+		provider string
+		first    string
+	}{
+		{"openai", "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n"},
+		{"anthropic", "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n"},
+		{"ollama", "{\"message\":{\"role\":\"assistant\",\"content\":\"Hel\"},\"done\":false}\n"},
+		{"gemini", "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hel\"}]}}]}\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			// The server sends one piece and then holds the stream open
+			// until the client goes away, which it only notices once the
+			// request body has been read
+			gone := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(io.Discard, r.Body)
+				io.WriteString(w, tt.first)
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+				close(gone)
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			chunks, err := testClient(tt.provider, srv.URL).StreamMessageContext(ctx, testMessages(), chat.Params{})
+			if err != nil {
+				t.Fatalf("StreamMessageContext: %v", err)
+			}
+
+			select {
+			case chunk := <-chunks:
+				if chunk.Content != "Hel" {
+					t.Fatalf("first chunk %+v, want content %q", chunk, "Hel")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no first chunk")
+			}
+
+			cancel()
+			if res := collect(t, chunks); res.err != nil {
+				t.Errorf("cancelled stream sent error %v", res.err)
+			}
+			select {
+			case <-gone:
+			case <-time.After(5 * time.Second):
+				t.Error("request was not closed after cancelling")
+			}
+		})
+	}
+}
+
+func TestSendCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := testClient("openai", srv.URL).SendMessageContext(ctx, testMessages(), chat.Params{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// checkAPIError checks that err is an APIError of the given status, kind
+// and message
+func checkAPIError(t *testing.T, err error, status int, kind error, message string) {
+	t.Helper()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got error %v (%T), want an *APIError", err, err)
+	}
+	if apiErr.StatusCode != status {
+		t.Errorf("status %d, want %d", apiErr.StatusCode, status)
+	}
+	if apiErr.Message != message {
+		t.Errorf("message %q, want %q", apiErr.Message, message)
+	}
+	if kind != nil && !errors.Is(err, kind) {
+		t.Errorf("error %v is not %v", err, kind)
+	}
+	if kind == nil && errors.Unwrap(err) != nil {
+		t.Errorf("error %v classified as %v, want unclassified", err, errors.Unwrap(err))
+	}
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// retryBaseDelay is the wait before the first retry; it doubles with
+	// every further attempt up to retryMaxDelay
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+
+	// maxRetryAfter is the longest server-requested wait we sit through.
+	// Beyond that the error is returned instead.
+	maxRetryAfter = 2 * time.Minute
+)
+
+// Retry describes a failed attempt that is about to be repeated
+type Retry struct {
+	Attempt     int           // Attempt that failed, counting from 1
+	MaxAttempts int           // Attempts that will be made in total
+	Delay       time.Duration // Wait before the next attempt
+	Err         error         // Why the attempt failed
+}
+
+type retryHookKey struct{}
+
+// WithRetryHook returns a context that makes requests sent with it call
+// hook before waiting to retry. The hook runs on the requesting goroutine.
+func WithRetryHook(ctx context.Context, hook func(Retry)) context.Context {
+	return context.WithValue(ctx, retryHookKey{}, hook)
+}
+
+// retryHookFrom returns the hook set by WithRetryHook, if any
+func retryHookFrom(ctx context.Context) func(Retry) {
+	hook, _ := ctx.Value(retryHookKey{}).(func(Retry))
+	return hook
+}
+
+// retryable reports whether a failed attempt is worth repeating:
+// transport failures, rate limits and server errors are, as long as the
+// caller has not given up
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable() && apiErr.RetryAfter <= maxRetryAfter
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// backoff returns how long to wait after a failed attempt. A wait asked
+// for by the server wins; otherwise the delay grows exponentially with
+// jitter so clients do not retry in lockstep.
+func backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	delay := retryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter reads how long the server wants us to wait before
+// retrying a response with the given status. Rate limits (429) may say
+// so in Retry-After or the providers' rate-limit headers; an unavailable
+// server (503) only in Retry-After. Other failures, and responses that
+// say nothing, return zero so the usual backoff applies.
+func parseRetryAfter(status int, h http.Header, now time.Time) time.Duration {
+	if status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			return at.Sub(now)
+		}
+	}
+
+	if status != http.StatusTooManyRequests {
+		return 0
+	}
+
+	// OpenAI and Groq send Go-style durations ("2m59.5s") and Anthropic
+	// sends timestamps. Only the limits that are used up matter; the
+	// others reset on their own schedule without blocking the retry.
+	var exhausted time.Duration
+	for name := range h {
+		lower := strings.ToLower(name)
+		isReset := strings.HasPrefix(lower, "x-ratelimit-reset-") ||
+			(strings.HasPrefix(lower, "anthropic-ratelimit-") && strings.HasSuffix(lower, "-reset"))
+		if !isReset {
+			continue
+		}
+		d, ok := parseReset(h.Get(name), now)
+		if !ok {
+			continue
+		}
+		remaining := strings.Replace(lower, "reset", "remaining", 1)
+		if h.Get(remaining) == "0" {
+			exhausted = max(exhausted, d)
+		}
+	}
+	return exhausted
+}
+
+// parseReset reads a rate-limit reset header, either a duration or a
+// timestamp
+func parseReset(v string, now time.Time) (time.Duration, bool) {
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d, true
+	}
+	if at, err := time.Parse(time.RFC3339, v); err == nil && at.After(now) {
+		return at.Sub(now), true
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxEventSize bounds a single server-sent event line
+const maxEventSize = 1024 * 1024
+
+// readSSE parses server-sent events from r and calls handle with each
+// event's name and data. Reading stops at the end of the body or when
+// handle returns an error; errStreamDone stops it cleanly.
+func readSSE(r io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+
+	var event string
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := handle(event, strings.Join(data, "\n"))
+		event, data = "", data[:0]
+		return err
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case line == "":
+			// A blank line ends the current event
+			if err := dispatch(); err != nil {
+				return ignoreDone(err)
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment or keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %w", err)
+	}
+
+	// Flush a final event that was not followed by a blank line
+	return ignoreDone(dispatch())
+}
+
+// errStreamDone is returned by event handlers to end a stream early
+var errStreamDone = fmt.Errorf("stream done")
+
+// ignoreDone maps errStreamDone to a clean finish
+func ignoreDone(err error) error {
+	if err == errStreamDone {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+// StreamChunk is a piece of a streamed completion. A chunk carries
+// content, reasoning reported separately by the API, a token usage report
+// or an error; an error chunk is always the last one sent before the
+// channel is closed.
+type StreamChunk struct {
+	Content   string
+	Reasoning string
+	Usage     *chat.Usage
+	Err       error
+}
+
+// StreamMessage sends a message to the LLM and streams the response back
+// over the returned channel. The channel is closed once the completion is
+// finished or has failed.
+func (c *Client) StreamMessage(messages []chat.Message) (<-chan StreamChunk, error) {
+	return c.StreamMessageContext(context.Background(), messages, c.Params())
+}
+
+// StreamMessageContext is like StreamMessage but uses the given parameters,
+// and stops the stream and closes the channel when ctx is cancelled. No
+// error chunk is sent for a cancelled stream.
+func (c *Client) StreamMessageContext(ctx context.Context, messages []chat.Message, params chat.Params) (<-chan StreamChunk, error) {
+	resp, err := c.do(ctx, messages, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		err := c.provider.ParseStream(resp.Body, func(chunk StreamChunk) error {
+			select {
+			case chunks <- chunk:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			select {
+			case chunks <- StreamChunk{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return chunks, nil
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+const (
+	// messageOverhead approximates the tokens each message costs for its
+	// role and framing
+	messageOverhead = 4
+
+	// defaultContextWindow is assumed for models we know nothing about
+	defaultContextWindow = 8192
+)
+
+// contextWindows maps model name prefixes to their context size in
+// tokens. Longer prefixes are listed first so they win.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini", 1048576},
+	{"deepseek", 128000},
+	{"llama-3.1", 131072},
+	{"llama-3.2", 131072},
+	{"llama-3.3", 131072},
+	{"llama3", 8192},
+	{"llama-3", 8192},
+	{"qwen", 32768},
+	{"mixtral", 32768},
+	{"mistral", 32768},
+	{"gemma", 8192},
+}
+
+// ContextWindow returns the context size of a model in tokens, falling
+// back to a conservative default for unknown models
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	// Strip any vendor prefix such as "meta-llama/"
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens approximates how many tokens text costs for a model.
+// It is deliberately simple: ASCII text averages about four characters a
+// token (a little less for Claude), while other scripts are closer to a
+// token per character.
+func EstimateTokens(model, text string) int {
+	charsPerToken := 4.0
+	if strings.Contains(strings.ToLower(model), "claude") {
+		charsPerToken = 3.5
+	}
+
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < 128 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return int(float64(ascii)/charsPerToken+0.5) + other
+}
+
+// MessageTokens approximates the tokens a message costs in a request
+func MessageTokens(model string, msg chat.Message) int {
+	return EstimateTokens(model, msg.Content) + messageOverhead
+}
+
+// HistoryTokens approximates the tokens a whole history costs
+func HistoryTokens(model string, messages []chat.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += MessageTokens(model, msg)
+	}
+	return total
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/yuin/goldmark"
+)
+
+// Export formats
+const (
+	FormatMarkdown = "md"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+)
+
+// Export renders a chat in the given format
+func Export(c *Chat, format string) ([]byte, error) {
+	switch format {
+	case FormatMarkdown, "markdown":
+		return ExportMarkdown(c), nil
+	case FormatJSON:
+		return ExportJSON(c)
+	case FormatHTML:
+		return ExportHTML(c)
+	default:
+		return nil, fmt.Errorf("unknown export format %q (want md, json or html)", format)
+	}
+}
+
+// FormatFromPath picks an export format from a file extension, falling
+// back to Markdown
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".html", ".htm":
+		return FormatHTML
+	default:
+		return FormatMarkdown
+	}
+}
+
+// ExportMarkdown renders a chat as a Markdown document
+func ExportMarkdown(c *Chat) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", c.DisplayTitle())
+	fmt.Fprintf(&b, "- Model: %s\n", c.Model)
+	fmt.Fprintf(&b, "- Created: %s\n", c.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "- Updated: %s\n", c.UpdatedAt.Format("2006-01-02 15:04"))
+
+	for _, msg := range c.ActivePath() {
+		if msg.Local() {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", roleTitle(msg.Role))
+		b.WriteString(strings.TrimSpace(msg.Content))
+		b.WriteString("\n")
+	}
+
+	return []byte(b.String())
+}
+
+// ExportJSON returns the chat as stored, including every branch
+func ExportJSON(c *Chat) ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding chat: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ExportHTML renders the Markdown export as a standalone HTML page. Raw
+// HTML in messages is omitted, leaving only a placeholder comment.
+func ExportHTML(c *Chat) ([]byte, error) {
+	var body bytes.Buffer
+	if err := goldmark.Convert(ExportMarkdown(c), &body); err != nil {
+		return nil, fmt.Errorf("error rendering chat: %w", err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(c.DisplayTitle()))
+	b.WriteString("<style>body{max-width:50em;margin:2em auto;padding:0 1em;font-family:sans-serif;line-height:1.5}pre{background:#f4f4f4;padding:.5em;overflow-x:auto}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	b.Write(body.Bytes())
+	b.WriteString("</body>\n</html>\n")
+	return b.Bytes(), nil
+}
+
+// roleTitle returns a heading for a message role
+func roleTitle(role chat.Role) string {
+	switch role {
+	case chat.RoleUser:
+		return "User"
+	case chat.RoleSystem:
+		return "System"
+	default:
+		return "Assistant"
+	}
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+// fileExt is the extension used for saved chat files
+const fileExt = ".json"
+
+// maxTitleLength caps the length of generated titles
+const maxTitleLength = 60
+
+// untitled names a chat that has no user message to take a title from.
+// It is only shown, never saved, so the title can still be derived later.
+const untitled = "Untitled chat"
+
+// ErrNotFound is returned when a chat does not exist in the store
+var ErrNotFound = errors.New("chat not found")
+
+// Chat is a saved conversation. The message tree is stored inline, so
+// files written before branching existed still load.
+type Chat struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Sampling parameters that override the configured ones for this
+	// conversation
+	Params *chat.Params `json:"params,omitempty"`
+
+	*chat.Conversation
+}
+
+// NewChat creates an empty chat with a fresh ID
+func NewChat(model string) *Chat {
+	now := time.Now()
+	return &Chat{
+		ID:           NewID(),
+		Model:        model,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Conversation: chat.NewConversation(),
+	}
+}
+
+// Store reads and writes chats as JSON files in a directory
+type Store struct {
+	dir string
+}
+
+// New creates a store backed by dir
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory the store writes to
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// DisplayTitle returns the chat's title, derived from its first user
+// message if it has none
+func (c *Chat) DisplayTitle() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	if title := Title(c.ActivePath()); title != "" {
+		return title
+	}
+	return untitled
+}
+
+// Save writes the chat to its own file, replacing any previous version.
+// The title is derived once the chat has a user message.
+func (s *Store) Save(c *Chat) error {
+	path, err := s.path(c.ID)
+	if err != nil {
+		return err
+	}
+
+	if c.Title == "" {
+		c.Title = Title(c.ActivePath())
+	}
+	c.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding chat: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create chats directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a
+	// truncated chat behind
+	tmp, err := os.CreateTemp(s.dir, "."+c.ID+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving chat: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving chat: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving chat: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving chat: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads the chat with the given ID
+func (s *Store) Load(id string) (*Chat, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return readChat(path)
+}
+
+// List returns all saved chats, most recently updated first
+func (s *Store) List() ([]*Chat, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading chats directory: %w", err)
+	}
+
+	var chats []*Chat
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != fileExt {
+			continue
+		}
+		c, err := readChat(filepath.Join(s.dir, name))
+		if err != nil {
+			// Skip files we cannot parse rather than hiding every chat
+			continue
+		}
+		chats = append(chats, c)
+	}
+
+	sort.Slice(chats, func(i, j int) bool {
+		return chats[i].UpdatedAt.After(chats[j].UpdatedAt)
+	})
+
+	return chats, nil
+}
+
+// Delete removes the chat with the given ID
+func (s *Store) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return fmt.Errorf("error deleting chat: %w", err)
+	}
+	return nil
+}
+
+// path returns the file path for a chat ID, rejecting IDs that would
+// escape the store directory
+func (s *Store) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid chat ID %q", id)
+	}
+	return filepath.Join(s.dir, id+fileExt), nil
+}
+
+// readChat decodes a chat file
+func readChat(path string) (*Chat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSuffix(filepath.Base(path), fileExt))
+		}
+		return nil, fmt.Errorf("error reading chat: %w", err)
+	}
+
+	var c Chat
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("error parsing chat %s: %w", filepath.Base(path), err)
+	}
+	if c.Conversation == nil {
+		c.Conversation = chat.NewConversation()
+	}
+	c.Normalize()
+
+	// Older versions saved the placeholder, which kept the title from
+	// ever being derived
+	if c.Title == untitled {
+		c.Title = ""
+	}
+	return &c, nil
+}
+
+// NewID returns a new chat ID that sorts by creation time
+func NewID() string {
+	var b [3]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().Format("20060102-150405.000000")
+	}
+	return time.Now().Format("20060102-150405-") + hex.EncodeToString(b[:])
+}
+
+// Title derives a chat title from its first user message, or returns ""
+// if there is none
+func Title(messages []chat.Message) string {
+	for _, msg := range messages {
+		if msg.Role != chat.RoleUser {
+			continue
+		}
+		title := strings.Join(strings.Fields(msg.Content), " ")
+		if runes := []rune(title); len(runes) > maxTitleLength {
+			title = string(runes[:maxTitleLength-1]) + "…"
+		}
+		return title
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package theme
+
+// builtin holds the themes that ship with gochat
+var builtin = map[string]Theme{
+	"dark": {
+		Border: "thick",
+		Roles: RoleColors{
+			User:      "5", // Magenta
+			Assistant: "4", // Blue
+			System:    "6", // Cyan
+			Focused:   "3", // Yellow
+			Error:     "1", // Red
+		},
+		Header:        "7",
+		Reasoning:     "243",
+		Error:         "9",
+		Status:        "245",
+		Text:          "7",
+		Muted:         "240",
+		Input:         "205",
+		Accent:        "170",
+		Highlight:     "205",
+		Selection:     "62",
+		SelectionText: "230",
+		Markdown:      Markdown{Base: "dark"},
+	},
+
+	"light": {
+		Border: "thick",
+		Roles: RoleColors{
+			User:      "127",
+			Assistant: "25",
+			System:    "30",
+			Focused:   "172",
+			Error:     "160",
+		},
+		Header:        "236",
+		Reasoning:     "244",
+		Error:         "160",
+		Status:        "242",
+		Text:          "236",
+		Muted:         "246",
+		Input:         "90",
+		Accent:        "127",
+		Highlight:     "161",
+		Selection:     "153",
+		SelectionText: "232",
+		Markdown:      Markdown{Base: "light"},
+	},
+
+	// Bright, bold colors for low-quality displays and low vision
+	"high-contrast": {
+		Border: "thick",
+		Roles: RoleColors{
+			User:      "13",
+			Assistant: "12",
+			System:    "14",
+			Focused:   "11",
+			Error:     "9",
+		},
+		Header:        "15",
+		Reasoning:     "250",
+		Error:         "9",
+		Status:        "15",
+		Text:          "15",
+		Muted:         "250",
+		Input:         "15",
+		Accent:        "11",
+		Highlight:     "11",
+		Selection:     "11",
+		SelectionText: "0",
+		Markdown: Markdown{
+			Base:    "dark",
+			Text:    "15",
+			Heading: "11",
+			Link:    "14",
+			Code:    "11",
+		},
+	},
+
+	// Ethan Schoonover's Solarized, dark variant
+	"solarized": {
+		Border: "thick",
+		Roles: RoleColors{
+			User:      "#d33682",
+			Assistant: "#268bd2",
+			System:    "#2aa198",
+			Focused:   "#b58900",
+			Error:     "#dc322f",
+		},
+		Header:        "#93a1a1",
+		Reasoning:     "#586e75",
+		Error:         "#dc322f",
+		Status:        "#657b83",
+		Text:          "#93a1a1",
+		Muted:         "#586e75",
+		Input:         "#2aa198",
+		Accent:        "#6c71c4",
+		Highlight:     "#cb4b16",
+		Selection:     "#073642",
+		SelectionText: "#eee8d5",
+		Markdown: Markdown{
+			Base:      "dark",
+			Text:      "#839496",
+			Heading:   "#268bd2",
+			Link:      "#2aa198",
+			Code:      "#cb4b16",
+			CodeTheme: "solarized-dark",
+		},
+	},
+}
@@ -0,0 +1,230 @@
+// Package theme defines the colors and styles of the interface. Built-in
+// themes can be extended or replaced by YAML files.
+package theme
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme holds every color and style the interface uses. Colors are
+// ANSI numbers ("5", "243") or hex values ("#268bd2").
+type Theme struct {
+	// Built-in theme a user theme starts from; dark if unset
+	Extends string `yaml:"extends"`
+
+	// Message border: thick, normal, rounded, double or hidden
+	Border string `yaml:"border"`
+
+	// Border color of each kind of message
+	Roles RoleColors `yaml:"roles"`
+
+	Header    lipgloss.Color `yaml:"header"`    // Message headers
+	Reasoning lipgloss.Color `yaml:"reasoning"` // Model reasoning
+	Error     lipgloss.Color `yaml:"error"`     // Error notice text
+	Status    lipgloss.Color `yaml:"status"`    // Status line
+	Text      lipgloss.Color `yaml:"text"`      // Popup entries
+	Muted     lipgloss.Color `yaml:"muted"`     // Placeholders, descriptions, dates
+	Input     lipgloss.Color `yaml:"input"`     // Draft being typed
+	Accent    lipgloss.Color `yaml:"accent"`    // Finder border and cursor
+	Highlight lipgloss.Color `yaml:"highlight"` // Search matches
+
+	// Selected completion
+	Selection     lipgloss.Color `yaml:"selection"`
+	SelectionText lipgloss.Color `yaml:"selection_text"`
+
+	Markdown Markdown `yaml:"markdown"`
+}
+
+// RoleColors are the border colors of messages
+type RoleColors struct {
+	User      lipgloss.Color `yaml:"user"`
+	Assistant lipgloss.Color `yaml:"assistant"`
+	System    lipgloss.Color `yaml:"system"`
+	Focused   lipgloss.Color `yaml:"focused"`
+	Error     lipgloss.Color `yaml:"error"`
+}
+
+// Markdown styles message bodies. It starts from one of glamour's styles
+// and overrides the colors that are set.
+type Markdown struct {
+	Base      string         `yaml:"base"` // dark, light, notty, ascii, pink or dracula
+	Text      lipgloss.Color `yaml:"text"`
+	Heading   lipgloss.Color `yaml:"heading"`
+	Link      lipgloss.Color `yaml:"link"`
+	Code      lipgloss.Color `yaml:"code"`       // Inline code
+	CodeTheme string         `yaml:"code_theme"` // Chroma style for code blocks
+}
+
+// borders are the accepted values of Border
+var borders = map[string]lipgloss.Border{
+	"thick":   lipgloss.ThickBorder(),
+	"normal":  lipgloss.NormalBorder(),
+	"rounded": lipgloss.RoundedBorder(),
+	"double":  lipgloss.DoubleBorder(),
+	"hidden":  lipgloss.HiddenBorder(),
+}
+
+// Names returns the built-in theme names in order
+func Names() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Auto returns the dark or light theme to suit the terminal background
+func Auto() *Theme {
+	if lipgloss.HasDarkBackground() {
+		return Builtin("dark")
+	}
+	return Builtin("light")
+}
+
+// Builtin returns a copy of a built-in theme, or nil if there is none
+// called name
+func Builtin(name string) *Theme {
+	t, ok := builtin[name]
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+// Load returns the theme called name. Empty, "auto" and "default" pick
+// dark or light to suit the terminal. Other names are built-in themes or
+// name.yaml in one of dirs; a name ending in .yaml is read as a path.
+func Load(name string, dirs []string) (*Theme, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(name) {
+	case "", "auto", "default":
+		return Auto(), nil
+	}
+	if t := Builtin(strings.ToLower(name)); t != nil {
+		return t, nil
+	}
+
+	if ext := filepath.Ext(name); ext == ".yaml" || ext == ".yml" {
+		return loadFile(name)
+	}
+	for _, dir := range dirs {
+		for _, ext := range []string{".yaml", ".yml"} {
+			path := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return loadFile(path)
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown theme %q (built in: %s; or add %s.yaml to a themes directory)", name, strings.Join(Names(), ", "), name)
+}
+
+// loadFile reads a user theme, layering it over the theme it extends
+func loadFile(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading theme: %w", err)
+	}
+
+	var header struct {
+		Extends string `yaml:"extends"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if header.Extends == "" {
+		header.Extends = "dark"
+	}
+	t := Builtin(strings.ToLower(header.Extends))
+	if t == nil {
+		return nil, fmt.Errorf("%s: extends unknown theme %q (built in: %s)", path, header.Extends, strings.Join(Names(), ", "))
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(t); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// validate checks the settings that are names rather than colors
+func (t *Theme) validate() error {
+	if _, ok := borders[t.Border]; !ok {
+		return fmt.Errorf("unknown border %q (want thick, normal, rounded, double or hidden)", t.Border)
+	}
+	if _, ok := glamour.DefaultStyles[t.Markdown.Base]; !ok {
+		return fmt.Errorf("unknown markdown base %q", t.Markdown.Base)
+	}
+	return nil
+}
+
+// BorderStyle returns the message border
+func (t *Theme) BorderStyle() lipgloss.Border {
+	if border, ok := borders[t.Border]; ok {
+		return border
+	}
+	return lipgloss.ThickBorder()
+}
+
+// MarkdownStyle returns the glamour style for message bodies
+func (t *Theme) MarkdownStyle() ansi.StyleConfig {
+	base, ok := glamour.DefaultStyles[t.Markdown.Base]
+	if !ok {
+		base = glamour.DefaultStyles["dark"]
+	}
+	style := *base
+
+	color := func(c lipgloss.Color) *string {
+		s := string(c)
+		return &s
+	}
+	md := t.Markdown
+	if md.Text != "" {
+		style.Document.Color = color(md.Text)
+	}
+	if md.Heading != "" {
+		style.Heading.Color = color(md.Heading)
+		// The first level is drawn as a label on a colored background
+		if style.H1.BackgroundColor != nil {
+			style.H1.BackgroundColor = color(md.Heading)
+		}
+	}
+	if md.Link != "" {
+		style.Link.Color = color(md.Link)
+		style.LinkText.Color = color(md.Link)
+	}
+	if md.Code != "" {
+		style.Code.Color = color(md.Code)
+	}
+	if md.CodeTheme != "" {
+		style.CodeBlock.Theme = md.CodeTheme
+		style.CodeBlock.Chroma = nil
+	}
+	return style
+}
+
+// Renderer returns a markdown renderer in this theme that wraps at
+// wordWrap columns, using the colors the terminal supports
+func (t *Theme) Renderer(wordWrap int) (*glamour.TermRenderer, error) {
+	return glamour.NewTermRenderer(
+		glamour.WithStyles(t.MarkdownStyle()),
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		glamour.WithWordWrap(wordWrap),
+	)
+}
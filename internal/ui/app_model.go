@@ -1,9 +1,15 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/saiashirwad/gochat/internal/config"
+	"github.com/saiashirwad/gochat/internal/storage"
+	"github.com/saiashirwad/gochat/internal/theme"
 )
 
 // AppModel is the main application model
@@ -14,12 +20,34 @@ type AppModel struct {
 	finderActive  bool
 	finderView    *FinderView
 	width, height int
+	inputHeight   int
+
+	// Watches the config file so edits apply without a restart
+	watcher *config.Watcher
+
+	// Why the configured theme could not be used, shown at startup
+	themeErr error
+}
+
+// configChangedMsg is sent when the config file has been saved
+type configChangedMsg struct{}
+
+// configLoadedMsg carries the config read again after a save, ready to
+// apply, or why it cannot be used
+type configLoadedMsg struct {
+	config *config.Config
+	theme  *theme.Theme
+	err    error
 }
 
 // NewAppModel creates a new instance of the application model
 func NewAppModel(cfg *config.Config) *AppModel {
+	// Styles are built before the views that copy them
+	themeErr := useTheme(cfg)
+
 	return &AppModel{
 		config:       cfg,
+		themeErr:     themeErr,
 		chatView:     NewChatView(cfg),
 		inputView:    NewInputView(cfg),
 		finderActive: false,
@@ -27,9 +55,106 @@ func NewAppModel(cfg *config.Config) *AppModel {
 	}
 }
 
+// LoadChat opens a saved conversation in the chat view
+func (m *AppModel) LoadChat(saved *storage.Chat) {
+	m.chatView.LoadChat(saved)
+}
+
 // Init initializes the model
 func (m *AppModel) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{m.chatView.Init()}
+	if m.themeErr != nil {
+		cmds = append(cmds, statusCmd("ui.theme: "+m.themeErr.Error()))
+	}
+	if path := m.config.Path(); path != "" {
+		watcher, err := config.Watch(path)
+		if err != nil {
+			cmds = append(cmds, statusCmd(err.Error()))
+		} else {
+			m.watcher = watcher
+			cmds = append(cmds, waitForConfigChange(watcher))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// Close stops watching the config file. It is called once the program
+// has exited.
+func (m *AppModel) Close() {
+	if m.watcher != nil {
+		m.watcher.Close()
+	}
+}
+
+// waitForConfigChange waits for the next save of the config file
+func waitForConfigChange(w *config.Watcher) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-w.Changes; !ok {
+			return nil
+		}
+		return configChangedMsg{}
+	}
+}
+
+// loadConfigCmd reads the config file again with the given profile
+// active. Validation and the API key lookup may run the keyring tool or
+// api_key_cmd, so this is done outside the event loop.
+func loadConfigCmd(profile string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.Load()
+		if err == nil {
+			err = cfg.UseProfile(profile)
+		}
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err == nil {
+			// The new client then finds the key without looking it up
+			err = cfg.ResolveAPIKey()
+		}
+		var t *theme.Theme
+		if err == nil {
+			t, err = theme.Load(cfg.UI.Theme, cfg.ThemeDirs())
+		}
+		return configLoadedMsg{config: cfg, theme: t, err: err}
+	}
+}
+
+// reloadConfig hands a config read again to every view. The running one
+// is never changed in place, since replies in flight may still use it.
+// An invalid file leaves the running settings alone.
+func (m *AppModel) reloadConfig(msg configLoadedMsg) tea.Cmd {
+	if msg.err != nil {
+		return statusCmd("Config not reloaded: " + reloadError(msg.err))
+	}
+
+	// The chat view holds the profile chosen with /profile, which may
+	// have changed while the file was read
+	if profile := m.chatView.config.Profile(); msg.config.Profile() != profile {
+		return loadConfigCmd(profile)
+	}
+
+	cfg := msg.config
+	applyTheme(msg.theme)
+	m.config = cfg
+	m.chatView.ApplyConfig(cfg)
+	m.inputView.ApplyConfig(cfg)
+	m.finderView.ApplyConfig(cfg)
+	m.layout()
+	return statusCmd("Config reloaded")
+}
+
+// reloadError condenses a config error to fit the status line
+func reloadError(err error) string {
+	var invalid *config.ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Problems) == 0 {
+		return err.Error()
+	}
+	text := invalid.Problems[0]
+	if more := len(invalid.Problems) - 1; more > 0 {
+		text += fmt.Sprintf(" (and %d more; see gochat config validate)", more)
+	}
+	return text
 }
 
 // Update handles events and updates the model
@@ -48,28 +173,48 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Initialize finder search
 				return m, m.finderView.Init()
 			}
-		case "i":
-			// Return to input mode if in chat focus mode
-			if !m.inputView.textInput.Focused() {
-				m.inputView.Focus()
-				m.chatView.focusActive = false
-			}
+			return m, nil
 		}
 
-	case tea.WindowSizeMsg:
-		m.width, m.height = msg.Width, msg.Height
+		// The finder takes every other key while it is open
+		if m.finderActive {
+			break
+		}
 
-		// Calculate heights
-		inputHeight := 1                     // Input box height (just content, no borders)
-		chatHeight := m.height - inputHeight // No extra space needed
-		if chatHeight < 5 {
-			chatHeight = 5 // Minimum chat height
+		// Hold the draft until the current reply has finished streaming
+		if key.Matches(msg, m.inputView.keys.Send) && m.chatView.Streaming() && m.inputView.Focused() {
+			return m, nil
 		}
+		// Return to input mode if in chat focus mode
+		if msg.String() == "i" && !m.inputView.Focused() {
+			m.inputView.Focus()
+			m.chatView.focusActive = false
+			m.chatView.updateContent()
+			return m, nil
+		}
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 
 		// Update sub-component sizes
-		m.chatView.SetSize(msg.Width, chatHeight)
 		m.inputView.SetWidth(msg.Width)
+		m.layout()
 		m.finderView.SetSize(msg.Width, msg.Height)
+
+	case closeFinderMsg:
+		m.finderActive = false
+		return m, nil
+
+	case configChangedMsg:
+		return m, tea.Batch(loadConfigCmd(m.chatView.config.Profile()), waitForConfigChange(m.watcher))
+
+	case configLoadedMsg:
+		return m, m.reloadConfig(msg)
+
+	case loadChatMsg:
+		// Close the finder and let the chat view open the selection
+		m.finderActive = false
+		m.inputView.Focus()
 	}
 
 	// Handle updates for sub-components
@@ -94,11 +239,26 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.inputView = newModel
 		}
 		cmds = append(cmds, cmd)
+
+		// Give the chat view whatever room the input no longer needs
+		if m.inputView.Height() != m.inputHeight {
+			m.layout()
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// layout splits the window between the chat and input views
+func (m *AppModel) layout() {
+	m.inputHeight = m.inputView.Height()
+	chatHeight := m.height - m.inputHeight // No extra space needed
+	if chatHeight < 5 {
+		chatHeight = 5 // Minimum chat height
+	}
+	m.chatView.SetSize(m.width, chatHeight)
+}
+
 // View renders the UI
 func (m *AppModel) View() string {
 	if m.finderActive {
@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+// editMessageMsg loads a message into the input view for editing
+type editMessageMsg struct {
+	content string
+}
+
+// editSubmitMsg carries the edited text of the message being edited
+type editSubmitMsg struct {
+	input string
+}
+
+// editCancelMsg abandons an edit
+type editCancelMsg struct{}
+
+// switchBranch moves to the next or previous sibling of the message at
+// index. It reports false if the message has no siblings.
+func (c *ChatView) switchBranch(index, step int) bool {
+	id := c.messages[index].ID
+	siblings := c.session.Siblings(id)
+	if len(siblings) < 2 {
+		return false
+	}
+
+	pos := 0
+	for i, sibling := range siblings {
+		if sibling.ID == id {
+			pos = i
+			break
+		}
+	}
+	n := len(siblings)
+	c.session.Select(siblings[((pos+step)%n+n)%n].ID)
+	c.refresh()
+	return true
+}
+
+// branchLabel describes which of its siblings a message is, if any
+func (c *ChatView) branchLabel(id string) string {
+	siblings := c.session.Siblings(id)
+	if len(siblings) < 2 {
+		return ""
+	}
+	for i, sibling := range siblings {
+		if sibling.ID == id {
+			return fmt.Sprintf(" (branch %d/%d)", i+1, len(siblings))
+		}
+	}
+	return ""
+}
+
+// regenerate asks for a new reply in place of the assistant message at
+// index, keeping the old reply as a sibling branch
+func (c *ChatView) regenerate(index int) tea.Cmd {
+	msg := c.messages[index]
+	if msg.Role != chat.RoleAssistant {
+		return statusCmd("Only replies can be regenerated")
+	}
+	hasPrompt := false
+	for _, prev := range c.messages[:index] {
+		if prev.Role == chat.RoleUser {
+			hasPrompt = true
+			break
+		}
+	}
+	if !hasPrompt {
+		return statusCmd("There is no message to reply to")
+	}
+	c.session.Rewind(msg.ID)
+	c.focusActive = false
+	return c.startReply()
+}
+
+// editMessage starts editing the user message at index
+func (c *ChatView) editMessage(index int) tea.Cmd {
+	msg := c.messages[index]
+	if msg.Role != chat.RoleUser {
+		return statusCmd("Only your own messages can be edited")
+	}
+	c.editID = msg.ID
+	c.focusActive = false
+	c.updateContent()
+	return func() tea.Msg {
+		return editMessageMsg{content: msg.Content}
+	}
+}
+
+// submitEdit resends an edited message as a sibling of the original
+func (c *ChatView) submitEdit(input string) tea.Cmd {
+	id := c.editID
+	c.editID = ""
+	if c.session.Get(id) == nil {
+		return nil
+	}
+	c.session.Rewind(id)
+	c.session.Append(chat.NewMessage(chat.RoleUser, input))
+	return c.startReply()
+}
+
+// deleteMessage removes the message at index. If the message is one of
+// several branches, that whole branch is removed instead and a sibling
+// takes its place.
+func (c *ChatView) deleteMessage(index int) tea.Cmd {
+	id := c.messages[index].ID
+	siblings := c.session.Siblings(id)
+	if len(siblings) > 1 {
+		next := siblings[0]
+		for i, sibling := range siblings {
+			if sibling.ID == id {
+				if i+1 < len(siblings) {
+					next = siblings[i+1]
+				} else {
+					next = siblings[i-1]
+				}
+				break
+			}
+		}
+		c.session.Prune(id)
+		c.session.Select(next.ID)
+		c.refresh()
+		return tea.Batch(c.autosave(), statusCmd("Branch deleted"))
+	}
+
+	c.session.Remove(id)
+	c.refresh()
+	return tea.Batch(c.autosave(), statusCmd("Message deleted"))
+}
+
+// clampFocus keeps the focus index inside the conversation
+func (c *ChatView) clampFocus() {
+	if c.focusIndex >= len(c.messages) {
+		c.focusIndex = len(c.messages) - 1
+	}
+	if c.focusIndex < 0 {
+		c.focusIndex = 0
+	}
+	if len(c.messages) == 0 {
+		c.focusActive = false
+	}
+}
+
+// focusAction runs a message action from focus mode, refusing while a
+// reply is streaming since the tree would change underneath it
+func (c *ChatView) focusAction(action func(index int) tea.Cmd) tea.Cmd {
+	if c.stream != nil {
+		return statusCmd("Wait for the reply to finish or cancel it first")
+	}
+	if c.focusIndex < 0 || c.focusIndex >= len(c.messages) {
+		return nil
+	}
+	return action(c.focusIndex)
+}
@@ -1,18 +1,20 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/muesli/termenv"
 	"github.com/saiashirwad/gochat/internal/chat"
 	"github.com/saiashirwad/gochat/internal/config"
 	"github.com/saiashirwad/gochat/internal/llm"
+	"github.com/saiashirwad/gochat/internal/storage"
 )
 
 // KeyMap defines the keybindings for the chat view
@@ -25,6 +27,24 @@ type KeyMap struct {
 	Down     key.Binding
 	Top      key.Binding
 	Bottom   key.Binding
+	Cancel   key.Binding
+	Retry    key.Binding
+
+	// Actions on the focused message
+	Regenerate key.Binding
+	Edit       key.Binding
+	Delete     key.Binding
+	PrevBranch key.Binding
+	NextBranch key.Binding
+
+	// Reasoning visibility
+	ToggleReasoning    key.Binding
+	ToggleAllReasoning key.Binding
+
+	// Copying the focused message
+	CopyMarkdown key.Binding
+	CopyText     key.Binding
+	CopyCode     key.Binding
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -62,76 +82,142 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("end", "G"),
 			key.WithHelp("End/G", "scroll to bottom"),
 		),
+		Cancel: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("Ctrl+x", "cancel request"),
+		),
+		Retry: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("Ctrl+r", "retry failed request"),
+		),
+		Regenerate: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "regenerate reply"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit and resend"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "delete message"),
+		),
+		PrevBranch: key.NewBinding(
+			key.WithKeys("h", "left"),
+			key.WithHelp("←/h", "previous branch"),
+		),
+		NextBranch: key.NewBinding(
+			key.WithKeys("l", "right"),
+			key.WithHelp("→/l", "next branch"),
+		),
+		ToggleReasoning: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle reasoning"),
+		),
+		ToggleAllReasoning: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "toggle all reasoning"),
+		),
+		CopyMarkdown: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy message markdown"),
+		),
+		CopyText: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy message text"),
+		),
+		CopyCode: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "copy code block"),
+		),
 	}
 }
 
-var (
-	// Style for the entire chat area
-	chatStyle = lipgloss.NewStyle()
+// ChatView displays the conversation history
+type ChatView struct {
+	config      *config.Config
+	messages    []chat.Message // Active path of the conversation tree
+	llmClient   *llm.Client
+	viewport    viewport.Model
+	width       int
+	height      int
+	focusIndex  int  // Index of currently focused message
+	focusActive bool // Whether message focus is active
+	keys        KeyMap
 
-	// Base message style - clean with thick left border
-	baseMessageStyle = lipgloss.NewStyle().
-				Width(100).
-				PaddingLeft(1).
-				PaddingRight(1).
-				BorderLeft(true).
-				BorderStyle(lipgloss.ThickBorder()).
-				MarginTop(-1) // Remove gap between messages
+	// In-flight request, streaming into a reply on the active path
+	stream *activeStream
 
-	// Style for user messages - magenta indicator
-	userMessageStyle = baseMessageStyle.Copy().
-				BorderLeftForeground(lipgloss.Color("5")) // Magenta border
+	// The current conversation, which owns the message tree
+	store   *storage.Store
+	session *storage.Chat
 
-	// Style for LLM messages - blue indicator
-	llmMessageStyle = baseMessageStyle.Copy().
-			BorderLeftForeground(lipgloss.Color("4")) // Blue border
+	// One-line feedback shown below the conversation
+	status   string
+	statusID int
 
-	// Style for focused message - yellow indicator
-	focusedMessageStyle = baseMessageStyle.Copy().
-				BorderLeftForeground(lipgloss.Color("3")) // Yellow border
+	// Last failure, shown below the conversation until the next request
+	notice *errorNotice
 
-	// Header styles - subtle emphasis, no margins
-	headerStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("7")). // Light gray
-			Bold(true).
-			PaddingLeft(1).
-			PaddingRight(1).
-			MarginBottom(0).
-			Height(1) // Force single line height
+	// ID of the message being edited, empty for none
+	editID string
 
-	// Markdown renderer
-	markdownRenderer *glamour.TermRenderer
+	// Model chosen with /model for this conversation, empty for the
+	// configured one
+	model string
 
-	// Default keybindings
-	keys = DefaultKeyMap()
-)
+	// Number of code blocks offered after the copy code key, waiting for
+	// a digit to pick one; zero when not asking
+	codeChoice int
 
-func init() {
-	// Force TrueColor support
-	lipgloss.SetColorProfile(termenv.TrueColor)
+	// Messages whose reasoning section is expanded
+	expanded map[string]bool
 
-	// Initialize markdown renderer with dark theme
-	var err error
-	markdownRenderer, err = glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(0), // Will be set dynamically based on width
-	)
-	if err != nil {
-		panic(err)
-	}
+	// Drawings of the visible messages, reused while they are unchanged
+	cache *renderCache
+
+	// Layout of the conversation in lines: the height and top of each
+	// message (tops has one more entry, the end of the last message), the
+	// notice drawn after them, the first line in view, and whether the
+	// view is at the end and should stay there as the conversation grows
+	heights      []int
+	tops         []int
+	layoutDirty  bool
+	noticeBlock  string
+	noticeHeight int
+	offset       int
+	following    bool
 }
 
-// ChatView displays the conversation history
-type ChatView struct {
-	config      *config.Config
-	messages    []chat.Message
-	llmClient   *llm.Client
-	viewport    viewport.Model
-	width       int
-	height      int
-	focusIndex  int  // Index of currently focused message
-	focusActive bool // Whether message focus is active
-	keys        KeyMap
+// activeStream tracks a streaming request so it can be cancelled and so
+// messages from superseded requests can be told apart
+type activeStream struct {
+	chunks  <-chan llm.StreamChunk
+	cancel  context.CancelFunc
+	replyID string // Message the reply streams into
+
+	// Retries of the opening request, and the status line showing them
+	retries  chan llm.Retry
+	statusID int
+
+	// When the request was sent and how long the first content took
+	started    time.Time
+	firstToken time.Duration
+
+	// Raw reply text, which may contain inline <think> tags, and
+	// reasoning the API reported separately
+	raw       strings.Builder
+	reasoning strings.Builder
+}
+
+// errorNotice is a failure shown below the conversation. It is not part
+// of the message tree, so it is never saved or sent to the model.
+type errorNotice struct {
+	err error
+
+	// Message the failed reply would have answered; empty if the failure
+	// was not a request that can be retried
+	parentID string
 }
 
 // NewChatView creates a new chat view
@@ -139,11 +225,15 @@ func NewChatView(cfg *config.Config) *ChatView {
 	c := &ChatView{
 		config:    cfg,
 		llmClient: llm.NewClient(cfg),
-		messages: []chat.Message{
-			chat.NewMessage(chat.RoleAssistant, "Welcome to GoChat! Type your message below and press Enter to send."),
-		},
-		keys: DefaultKeyMap(),
+		keys:      configKeyMap(cfg),
+		store:     storage.New(cfg.Storage.ChatsDir),
+		session:   storage.NewChat(cfg.LLM.Model),
+		expanded:  make(map[string]bool),
+		cache:     newRenderCache(),
+		following: true,
 	}
+	c.session.Append(welcomeMessage())
+	c.messages = c.session.ActivePath()
 
 	// Initialize viewport with minimum size
 	c.viewport = viewport.New(10, 10)
@@ -153,46 +243,169 @@ func NewChatView(cfg *config.Config) *ChatView {
 	return c
 }
 
+// ApplyConfig switches the view to a new configuration. A reply that is
+// streaming finishes with the old client, which keeps its own settings.
+func (c *ChatView) ApplyConfig(cfg *config.Config) {
+	c.config = cfg
+	c.llmClient = c.newClient()
+	c.keys = configKeyMap(c.config)
+	c.store = storage.New(c.config.Storage.ChatsDir)
+	c.syncSession()
+	c.SetSize(c.width, c.height)
+}
+
+// newClient returns a client for the configured settings and the
+// conversation's model
+func (c *ChatView) newClient() *llm.Client {
+	client := llm.NewClient(c.config)
+	if c.model != "" {
+		client = client.WithModel(c.model)
+	}
+	return client
+}
+
+// resetModel drops the conversation's model choice when another
+// conversation is shown
+func (c *ChatView) resetModel() {
+	if c.model != "" {
+		c.model = ""
+		c.llmClient = c.newClient()
+	}
+}
+
+// welcomeMessage returns the greeting shown at the start of a conversation
+func welcomeMessage() chat.Message {
+	msg := chat.NewMessage(chat.RoleAssistant, "Welcome to GoChat! Type your message below and press Enter to send. Type /help for commands.")
+	msg.SetMeta(chat.MetaLocal, true)
+	return msg
+}
+
+// metaTruncation records on a reply how its history was shortened to fit
+// the context window
+const metaTruncation = "truncation"
+
 // SetSize updates the size of the chat view
 func (c *ChatView) SetSize(width, height int) {
 	c.width = width
 	c.height = height
 	c.viewport.Width = width
-	c.viewport.Height = height
+	c.viewport.Height = c.viewportHeight()
 
-	// Set widths for all styles
+	// Set widths for all styles. Message widths exclude the left border;
+	// headers, reasoning and markdown fit inside the padding.
 	chatStyle = chatStyle.Width(width)
-	messageWidth := width
+	messageWidth := c.messageWidth() - 1
 	baseMessageStyle = baseMessageStyle.Width(messageWidth)
 	userMessageStyle = userMessageStyle.Width(messageWidth)
 	llmMessageStyle = llmMessageStyle.Width(messageWidth)
+	systemMessageStyle = systemMessageStyle.Width(messageWidth)
 	focusedMessageStyle = focusedMessageStyle.Width(messageWidth)
-	headerStyle = headerStyle.Width(messageWidth)
+	errorMessageStyle = errorMessageStyle.Width(messageWidth)
+	headerStyle = headerStyle.Width(c.contentWidth())
 
 	// Update markdown renderer with new width
-	markdownRenderer, _ = glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(messageWidth-2), // Account for padding
-	)
+	markdownRenderer, _ = currentTheme.Renderer(c.contentWidth())
 
 	// Update content after resize
+	c.layoutDirty = true
 	c.updateContent()
 }
 
+// messageWidth returns the width messages are drawn at: the window
+// width, capped at ui.max_width
+func (c *ChatView) messageWidth() int {
+	width := c.width
+	if max := c.config.UI.MaxWidth; max > 0 && width > max {
+		width = max
+	}
+	return width
+}
+
+// contentWidth returns the width of the text inside a message, within
+// its border and padding
+func (c *ChatView) contentWidth() int {
+	width := c.messageWidth() - 3
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
+
+// align places a rendered message within the chat area according to
+// ui.align
+func (c *ChatView) align(block string) string {
+	if strings.EqualFold(c.config.UI.Align, "center") {
+		return lipgloss.PlaceHorizontal(c.width, lipgloss.Center, block)
+	}
+	return block
+}
+
 // Init initializes the chat view
 func (c *ChatView) Init() tea.Cmd {
-	return nil
+	return headerTick()
 }
 
-// sendMessageCmd creates a command to send a message to the LLM
-func sendMessageCmd(client *llm.Client, messages []chat.Message) tea.Cmd {
+// Streaming reports whether an assistant reply is currently being streamed
+func (c *ChatView) Streaming() bool {
+	return c.stream != nil
+}
+
+// startStreamCmd creates a command that fits the history into the
+// context window and opens a streaming request to the LLM
+func startStreamCmd(ctx context.Context, stream *activeStream, client *llm.Client, messages []chat.Message, params chat.Params) tea.Cmd {
 	return func() tea.Msg {
-		response, err := client.SendMessage(messages)
-		if err != nil {
-			return errMsg{err}
+		// Report retries until the stream has opened or failed
+		defer close(stream.retries)
+		ctx := llm.WithRetryHook(ctx, func(retry llm.Retry) {
+			select {
+			case stream.retries <- retry:
+			case <-ctx.Done():
+			}
+		})
+
+		messages, truncation, err := client.PrepareHistory(ctx, messages, params)
+		if err == nil {
+			var chunks <-chan llm.StreamChunk
+			chunks, err = client.StreamMessageContext(ctx, messages, params)
+			if err == nil {
+				return streamStartedMsg{stream: stream, chunks: chunks, truncation: truncation}
+			}
+		}
+		if ctx.Err() != nil {
+			// Cancelled before the response arrived
+			return streamDoneMsg{stream: stream}
+		}
+		return streamErrMsg{stream: stream, err: err}
+	}
+}
+
+// waitForRetry creates a command that reports the next retry of a
+// stream's opening request
+func waitForRetry(stream *activeStream) tea.Cmd {
+	return func() tea.Msg {
+		retry, ok := <-stream.retries
+		if !ok {
+			return nil
 		}
-		return newMessageMsg{
-			message: chat.NewMessage(chat.RoleAssistant, response),
+		return retryMsg{stream: stream, retry: retry}
+	}
+}
+
+// waitForChunk creates a command that waits for the next piece of a stream
+func waitForChunk(stream *activeStream) tea.Cmd {
+	return func() tea.Msg {
+		chunk, ok := <-stream.chunks
+		if !ok {
+			return streamDoneMsg{stream: stream}
+		}
+		if chunk.Err != nil {
+			return streamErrMsg{stream: stream, err: chunk.Err}
+		}
+		return streamChunkMsg{
+			stream:    stream,
+			content:   chunk.Content,
+			reasoning: chunk.Reasoning,
+			usage:     chunk.Usage,
 		}
 	}
 }
@@ -202,6 +415,38 @@ type newMessageMsg struct {
 	message chat.Message
 }
 
+// Stream message types
+type streamStartedMsg struct {
+	stream     *activeStream
+	chunks     <-chan llm.StreamChunk
+	truncation llm.Truncation
+}
+
+type streamChunkMsg struct {
+	stream    *activeStream
+	content   string
+	reasoning string
+	usage     *chat.Usage
+}
+
+type streamDoneMsg struct {
+	stream *activeStream
+}
+
+// retryMsg reports that a request failed and will be retried
+type retryMsg struct {
+	stream *activeStream
+	retry  llm.Retry
+}
+
+// streamErrMsg reports that a stream's request failed
+type streamErrMsg struct {
+	stream *activeStream
+	err    error
+}
+
+// errMsg reports a failure unrelated to the request in flight, such as a
+// save that did not work. It is shown in the status line.
 type errMsg struct {
 	err error
 }
@@ -209,41 +454,77 @@ type errMsg struct {
 // Message type for focusing chats
 type focusChatsMsg struct{}
 
-// preprocessContent converts special tags to markdown and handles other content preprocessing
-func preprocessContent(content string) string {
-	// Convert thinking tags to markdown italics
-	// This handles both <think> and </think> tags
-	content = strings.ReplaceAll(content, "<think>", "*")
-	content = strings.ReplaceAll(content, "</think>", "*")
-	return content
+// statusMsg shows a line of feedback below the conversation
+type statusMsg struct {
+	text string
+}
+
+// clearStatusMsg hides the status line unless it has changed since
+type clearStatusMsg struct {
+	id int
+}
+
+// statusTimeout is how long a status line stays visible
+const statusTimeout = 4 * time.Second
+
+// statusCmd creates a command that shows text in the status line
+func statusCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		return statusMsg{text: text}
+	}
+}
+
+// loadChatMsg replaces the current conversation with a saved one
+type loadChatMsg struct {
+	chat *storage.Chat
 }
 
 // Update handles events for the chat view
 func (c *ChatView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress {
+			switch msg.Button {
+			case tea.MouseButtonWheelUp:
+				c.scrollBy(-mouseWheelLines)
+			case tea.MouseButtonWheelDown:
+				c.scrollBy(mouseWheelLines)
+			}
+		}
+		return c, nil
 	case tea.KeyMsg:
+		if key.Matches(msg, c.keys.Cancel) && c.stream != nil {
+			c.cancelStream()
+			return c, c.autosave()
+		}
+		if key.Matches(msg, c.keys.Retry) && c.stream == nil && c.notice != nil && c.notice.parentID != "" {
+			return c, c.retryFailed()
+		}
 		if !c.focusActive {
 			switch {
 			case key.Matches(msg, c.keys.PageUp):
-				c.viewport.ViewUp()
+				c.scrollBy(-c.viewport.Height)
 			case key.Matches(msg, c.keys.PageDown):
-				c.viewport.ViewDown()
+				c.scrollBy(c.viewport.Height)
 			case key.Matches(msg, c.keys.HalfUp):
-				c.viewport.HalfViewUp()
+				c.scrollBy(-c.viewport.Height / 2)
 			case key.Matches(msg, c.keys.HalfDown):
-				c.viewport.HalfViewDown()
+				c.scrollBy(c.viewport.Height / 2)
 			case key.Matches(msg, c.keys.Up):
-				c.viewport.LineUp(1)
+				c.scrollBy(-1)
 			case key.Matches(msg, c.keys.Down):
-				c.viewport.LineDown(1)
+				c.scrollBy(1)
 			case key.Matches(msg, c.keys.Top):
-				c.viewport.GotoTop()
+				c.gotoTop()
 			case key.Matches(msg, c.keys.Bottom):
-				c.viewport.GotoBottom()
+				c.gotoBottom()
 			}
 		} else {
+			if c.codeChoice > 0 {
+				if cmd, ok := c.chooseCode(msg); ok {
+					return c, cmd
+				}
+			}
 			switch msg.String() {
 			case "j", "tab":
 				c.focusIndex++
@@ -260,118 +541,532 @@ func (c *ChatView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "esc":
 				c.focusActive = false
 				c.updateContent()
+			default:
+				switch {
+				case key.Matches(msg, c.keys.Regenerate):
+					return c, c.focusAction(c.regenerate)
+				case key.Matches(msg, c.keys.Edit):
+					return c, c.focusAction(c.editMessage)
+				case key.Matches(msg, c.keys.Delete):
+					return c, c.focusAction(c.deleteMessage)
+				case key.Matches(msg, c.keys.ToggleReasoning):
+					if c.focusIndex < len(c.messages) {
+						id := c.messages[c.focusIndex].ID
+						c.expanded[id] = !c.expanded[id]
+						c.updateContent()
+					}
+				case key.Matches(msg, c.keys.ToggleAllReasoning):
+					c.toggleAllReasoning()
+				case key.Matches(msg, c.keys.CopyMarkdown):
+					return c, c.copyAction(c.copyMarkdown)
+				case key.Matches(msg, c.keys.CopyText):
+					return c, c.copyAction(c.copyText)
+				case key.Matches(msg, c.keys.CopyCode):
+					return c, c.copyAction(c.copyCode)
+				case key.Matches(msg, c.keys.PrevBranch), key.Matches(msg, c.keys.NextBranch):
+					step := 1
+					if key.Matches(msg, c.keys.PrevBranch) {
+						step = -1
+					}
+					return c, c.focusAction(func(index int) tea.Cmd {
+						if !c.switchBranch(index, step) {
+							return statusCmd("No other branches here")
+						}
+						c.clampFocus()
+						c.updateContent()
+						return c.autosave()
+					})
+				}
 			}
 		}
 
 	case newMessageMsg:
-		c.messages = append(c.messages, msg.message)
-		c.updateContent()
-		c.viewport.GotoBottom()
-		return c, nil
+		if msg.message.Reasoning == "" {
+			msg.message.Reasoning, msg.message.Content = chat.ExtractReasoning(msg.message.Content)
+		}
+		c.session.Append(msg.message)
+		c.refresh()
+		c.gotoBottom()
+		return c, c.autosave()
+	case streamStartedMsg:
+		if msg.stream != c.stream {
+			return c, nil
+		}
+		c.stream.chunks = msg.chunks
+		c.clearRetryStatus()
+		if !msg.truncation.Truncated() {
+			return c, waitForChunk(c.stream)
+		}
+		if reply := c.session.Get(c.stream.replyID); reply != nil {
+			reply.SetMeta(metaTruncation, msg.truncation.String())
+			c.updateContent()
+		}
+		return c, tea.Batch(
+			waitForChunk(c.stream),
+			statusCmd("Context window full, "+msg.truncation.String()),
+		)
+	case retryMsg:
+		if msg.stream != c.stream {
+			return c, nil
+		}
+		c.setStatus(retryStatus(msg.retry))
+		c.stream.statusID = c.statusID
+		return c, waitForRetry(c.stream)
+	case streamChunkMsg:
+		if msg.stream != c.stream {
+			return c, nil
+		}
+		reply := c.session.Get(c.stream.replyID)
+		if reply == nil {
+			// The reply was removed from the conversation
+			c.abandonStream()
+			c.refresh()
+			return c, nil
+		}
+		if c.stream.firstToken == 0 && (msg.content != "" || msg.reasoning != "") {
+			c.stream.firstToken = time.Since(c.stream.started)
+		}
+		c.stream.raw.WriteString(msg.content)
+		c.stream.reasoning.WriteString(msg.reasoning)
+		thought, answer := chat.ExtractReasoning(c.stream.raw.String())
+		reply.Content = answer
+		reply.Reasoning = joinReasoning(c.stream.reasoning.String(), thought)
+		if msg.usage != nil {
+			reply.Usage = msg.usage
+		}
+		c.refreshMessage(reply.ID)
+		if !c.focusActive {
+			c.gotoBottom()
+		}
+		return c, waitForChunk(c.stream)
+	case streamDoneMsg:
+		if msg.stream != c.stream {
+			return c, nil
+		}
+		c.finishStream()
+		c.refresh()
+		return c, c.autosave()
+	case streamErrMsg:
+		if msg.stream != c.stream {
+			return c, nil
+		}
+		// The request failed, so it can be retried from the message it
+		// was answering
+		reply := c.session.Get(c.stream.replyID)
+		if reply == nil {
+			c.abandonStream()
+			c.refresh()
+			return c, nil
+		}
+		c.notice = &errorNotice{err: msg.err, parentID: reply.ParentID}
+
+		// Drop the reply placeholder if nothing was streamed into it, and
+		// keep what was out of the history otherwise
+		if reply.Content == "" {
+			c.abandonStream()
+		} else {
+			reply.Failed = true
+		}
+		c.finishStream()
+		c.refresh()
+		c.gotoBottom()
+		return c, c.autosave()
 	case errMsg:
-		c.messages = append(c.messages, chat.NewMessage(chat.RoleAssistant, fmt.Sprintf("Error: %v", msg.err)))
-		c.updateContent()
-		c.viewport.GotoBottom()
-		return c, nil
+		// Keep the notice for request failures, which can be retried
+		return c, statusCmd(msg.err.Error())
 	case userInputMsg:
+		if c.stream != nil {
+			return c, nil
+		}
 		// Add user message to history
-		userMessage := chat.NewMessage(chat.RoleUser, msg.input)
-		c.messages = append(c.messages, userMessage)
-		c.updateContent()
-		c.viewport.GotoBottom()
+		c.session.Append(chat.NewMessage(chat.RoleUser, msg.input))
 		// Send to LLM
-		return c, sendMessageCmd(c.llmClient, c.messages)
+		return c, c.startReply()
+	case editSubmitMsg:
+		if c.stream != nil {
+			return c, nil
+		}
+		return c, c.submitEdit(msg.input)
+	case editCancelMsg:
+		c.editID = ""
+		c.updateContent()
+		return c, nil
+	case commandMsg:
+		if msg.command.ChangesTree && c.stream != nil {
+			return c, statusCmd("Wait for the reply to finish or cancel it first")
+		}
+		return c, msg.command.Run(c, msg.args)
+	case statusMsg:
+		c.setStatus(msg.text)
+		id := c.statusID
+		return c, tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+			return clearStatusMsg{id: id}
+		})
+	case clearStatusMsg:
+		if msg.id == c.statusID {
+			c.setStatus("")
+		}
+		return c, nil
+	case loadChatMsg:
+		c.LoadChat(msg.chat)
+		return c, nil
+	case headerTickMsg:
+		// Refresh relative times, unless it would move the view away
+		// from what the user scrolled back to read
+		relative := !strings.EqualFold(c.config.UI.TimestampFormat, "absolute")
+		if c.config.UI.ShowTimestamp && relative && (c.focusActive || c.atBottom()) {
+			c.updateContent()
+		}
+		return c, headerTick()
 	case focusChatsMsg:
 		c.focusActive = true
 		c.focusIndex = len(c.messages) - 1
 		c.updateContent()
 	}
 
-	// Handle viewport messages
-	c.viewport, cmd = c.viewport.Update(msg)
-	return c, cmd
+	return c, nil
 }
 
-// updateContent updates the viewport content with formatted messages
-func (c *ChatView) updateContent() {
-	var formattedMessages []string
-	var totalHeight int
+// startReply adds an empty assistant message after the current one and
+// streams the LLM's reply to the conversation so far into it
+func (c *ChatView) startReply() tea.Cmd {
+	c.notice = nil
+	history := c.session.History()
+	params := c.params()
+
+	// Add an empty assistant message that the stream fills in
+	reply := chat.NewMessage(chat.RoleAssistant, "")
+	reply.Model = c.llmClient.Model()
+	reply.Profile = c.llmClient.Profile()
+	if !params.IsZero() {
+		reply.Params = &params
+	}
+	replyID := c.session.Append(reply).ID
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stream = &activeStream{
+		cancel:  cancel,
+		replyID: replyID,
+		retries: make(chan llm.Retry, 1),
+		started: time.Now(),
+	}
+	c.refresh()
+	c.gotoBottom()
+	return tea.Batch(
+		startStreamCmd(ctx, c.stream, c.llmClient, history, params),
+		waitForRetry(c.stream),
+	)
+}
 
-	// First pass: format messages and calculate heights
-	messageHeights := make([]int, len(c.messages))
-	for i, msg := range c.messages {
-		var content string
-		var style lipgloss.Style
+// retryFailed repeats the request that failed, answering the same message
+func (c *ChatView) retryFailed() tea.Cmd {
+	parentID := c.notice.parentID
+	if c.session.Get(parentID) == nil {
+		c.notice = nil
+		c.updateContent()
+		return statusCmd("Nothing to retry")
+	}
+	c.session.Current = parentID
+	return c.startReply()
+}
 
-		// Preprocess content to handle special tags
-		processedContent := preprocessContent(msg.Content)
+// params returns the sampling parameters for the next request: the
+// configured defaults with the conversation's overrides applied
+func (c *ChatView) params() chat.Params {
+	params := c.llmClient.Params()
+	if c.session.Params != nil {
+		params = params.With(*c.session.Params)
+	}
+	return params
+}
 
-		// Render content as markdown
-		rendered, err := markdownRenderer.Render(processedContent)
-		if err != nil {
-			rendered = processedContent // Fallback to plain text if markdown rendering fails
+// retry asks again for a reply to the last user message. Earlier replies
+// are kept as sibling branches.
+func (c *ChatView) retry() tea.Cmd {
+	if c.stream != nil {
+		return nil
+	}
+	last := ""
+	for _, msg := range c.messages {
+		if msg.Role == chat.RoleUser {
+			last = msg.ID
 		}
-		rendered = strings.TrimSpace(rendered) // Remove extra newlines from glamour
+	}
+	if last == "" {
+		return statusCmd("Nothing to retry")
+	}
+	c.session.Current = last
+	return c.startReply()
+}
+
+// NewChat starts a fresh conversation. The previous one stays saved.
+func (c *ChatView) NewChat() {
+	c.abandonStream()
+	c.resetModel()
+	c.session = storage.NewChat(c.llmClient.Model())
+	c.session.Append(welcomeMessage())
+	c.notice = nil
+	c.editID = ""
+	c.focusActive = false
+	c.focusIndex = 0
+	c.refresh()
+	c.gotoBottom()
+}
+
+// LoadChat replaces the current conversation with a saved one, abandoning
+// any in-flight request
+func (c *ChatView) LoadChat(saved *storage.Chat) {
+	c.abandonStream()
+	c.resetModel()
+	c.session = saved
+	c.notice = nil
+	c.editID = ""
+	c.focusActive = false
+	c.focusIndex = 0
+	c.refresh()
+	c.gotoBottom()
+}
+
+// refresh rebuilds the visible messages from the active path of the
+// conversation tree and re-renders them
+func (c *ChatView) refresh() {
+	c.messages = c.session.ActivePath()
+	c.cache.prune(c.messages)
+	c.layoutDirty = true
+	c.clampFocus()
+	c.updateContent()
+}
 
-		// Add header based on role
-		header := "LLM Message"
+// refreshMessage re-reads one message of the active path after its
+// content changed, measuring only that message again
+func (c *ChatView) refreshMessage(id string) {
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if c.messages[i].ID == id {
+			c.messages[i] = *c.session.Get(id)
+			c.remeasure(i)
+			c.updateContent()
+			return
+		}
+	}
+	c.refresh()
+}
+
+// autosave writes the current conversation to the store. Conversations
+// are only saved once the user has said something.
+func (c *ChatView) autosave() tea.Cmd {
+	hasUserMessage := false
+	for _, msg := range c.session.Messages {
 		if msg.Role == chat.RoleUser {
-			header = "My message"
+			hasUserMessage = true
+			break
 		}
-		header = headerStyle.Render(header)
+	}
+	if !hasUserMessage {
+		return nil
+	}
+
+	c.syncSession()
+	if err := c.store.Save(c.session); err != nil {
+		return func() tea.Msg {
+			return errMsg{fmt.Errorf("autosave failed: %w", err)}
+		}
+	}
+	return nil
+}
 
-		// Join header and content without gaps
-		content = header + "\n" + rendered
+// syncSession records the current settings on the saved chat
+func (c *ChatView) syncSession() {
+	c.session.Model = c.llmClient.Model()
+}
 
-		// Apply appropriate style based on role and focus
-		if c.focusActive && i == c.focusIndex {
-			style = focusedMessageStyle
-		} else if msg.Role == chat.RoleUser {
-			style = userMessageStyle
-		} else {
-			style = llmMessageStyle
+// finishStream clears the streaming state once a reply is complete
+func (c *ChatView) finishStream() {
+	if c.stream == nil {
+		return
+	}
+	if reply := c.session.Get(c.stream.replyID); reply != nil {
+		reply.Timestamp = time.Now()
+		reply.Timing = &chat.Timing{
+			FirstToken: c.stream.firstToken,
+			Total:      time.Since(c.stream.started),
 		}
+	}
+	c.clearRetryStatus()
+	c.stream.cancel()
+	c.stream = nil
+}
+
+// abandonStream stops the in-flight request without keeping its reply
+func (c *ChatView) abandonStream() {
+	if c.stream == nil {
+		return
+	}
+	c.clearRetryStatus()
+	c.stream.cancel()
+	c.session.Remove(c.stream.replyID)
+	c.stream = nil
+	c.refresh()
+}
+
+// setStatus changes the status line, resizing the viewport to make room
+func (c *ChatView) setStatus(text string) {
+	c.status = text
+	c.statusID++
+	c.viewport.Height = c.viewportHeight()
+	c.updateContent()
+}
+
+// retryStatus describes a pending retry for the status line
+func retryStatus(retry llm.Retry) string {
+	reason := "Request failed"
+	switch {
+	case errors.Is(retry.Err, llm.ErrRateLimited):
+		reason = "Rate limited"
+	case errors.Is(retry.Err, llm.ErrServer):
+		reason = "Server error"
+	}
+	return fmt.Sprintf("%s, retrying in %s (attempt %d of %d)…",
+		reason, retry.Delay.Round(100*time.Millisecond), retry.Attempt+1, retry.MaxAttempts)
+}
+
+// clearRetryStatus hides the retry progress of the in-flight request,
+// unless something else has been shown since
+func (c *ChatView) clearRetryStatus() {
+	if c.stream != nil && c.stream.statusID != 0 && c.stream.statusID == c.statusID {
+		c.setStatus("")
+	}
+}
+
+// viewportHeight returns the rows left for messages after the status line
+func (c *ChatView) viewportHeight() int {
+	if c.status != "" && c.height > 1 {
+		return c.height - 1
+	}
+	return c.height
+}
 
-		// Apply the style and add to messages
-		formattedMsg := style.Render(content)
-		formattedMessages = append(formattedMessages, formattedMsg)
+// cancelStream aborts the in-flight request and keeps whatever part of
+// the reply has arrived, marked as cancelled
+func (c *ChatView) cancelStream() {
+	if c.stream == nil {
+		return
+	}
+	if reply := c.session.Get(c.stream.replyID); reply != nil {
+		reply.Cancelled = true
+	}
+	c.finishStream()
+	c.refresh()
+}
 
-		// Calculate height of this message (count newlines + 1)
-		height := strings.Count(formattedMsg, "\n") + 1
-		messageHeights[i] = height
-		totalHeight += height
+// updateContent lays out the conversation after a change and draws the
+// part of it that is in view. Messages are only measured again when the
+// conversation, width or theme changes, and the focused message in case
+// it was the one that changed.
+func (c *ChatView) updateContent() {
+	if c.layoutDirty || len(c.tops) != len(c.messages)+1 {
+		c.measure()
+	} else if c.focusActive {
+		c.remeasure(c.focusIndex)
 	}
 
-	// Join messages and set content
-	content := strings.Join(formattedMessages, "\n")
-	c.viewport.SetContent(content)
+	// Show the last failure after the messages
+	c.noticeBlock, c.noticeHeight = "", 0
+	if c.notice != nil {
+		c.noticeBlock = c.align(c.renderNotice())
+		c.noticeHeight = strings.Count(c.noticeBlock, "\n") + 1
+	}
 
-	// Adjust scrolling only when necessary
+	// Keep the focused message in view, or follow new messages when the
+	// end of the conversation was showing
 	if c.focusActive {
-		// Calculate the position of the focused message
-		var focusedMsgTop int
-		for i := 0; i < c.focusIndex; i++ {
-			focusedMsgTop += messageHeights[i]
-		}
-		focusedMsgBottom := focusedMsgTop + messageHeights[c.focusIndex]
+		c.scrollToFocus()
+	} else if c.following {
+		c.offset = c.maxOffset()
+	}
+	c.renderWindow()
+}
+
+// renderNotice formats the error notice with a hint on what to do next
+func (c *ChatView) renderNotice() string {
+	header := "Error"
+	if c.notice.parentID != "" {
+		header += " (" + c.keys.Retry.Help().Key + " to retry)"
+	}
+	body := c.notice.err.Error()
+	if hint := errorHint(c.notice.err); hint != "" {
+		body += "\n" + hint
+	}
+	return errorMessageStyle.Render(headerStyle.Render(header) + "\n" + body)
+}
+
+// errorHint suggests a fix for the kinds of API failure that have one
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, llm.ErrAuth):
+		return "Store a key with `gochat auth set`, or check llm.api_key_cmd and GOCHAT_LLM_API_KEY."
+	case errors.Is(err, llm.ErrContextLength):
+		return "The conversation is too long for the model. Start over with /clear or lower llm.context_window."
+	case errors.Is(err, llm.ErrRateLimited):
+		return "Still rate limited after retrying. Wait a moment before trying again."
+	case errors.Is(err, llm.ErrServer):
+		return "The provider is having trouble. Try again in a moment."
+	}
+	return ""
+}
+
+// renderReasoning renders a message's reasoning as a dimmed section that
+// is collapsed to a single line unless expanded
+func (c *ChatView) renderReasoning(msg chat.Message, thinking bool) string {
+	lines := strings.Count(msg.Reasoning, "\n") + 1
+	label := "Reasoning"
+	if thinking {
+		label = "Thinking…"
+	}
+
+	if !c.expanded[msg.ID] {
+		return reasoningStyle.Render(fmt.Sprintf("▸ %s (%d lines, t to expand)", label, lines))
+	}
+
+	body := reasoningStyle.Copy().Width(c.contentWidth()).Render(msg.Reasoning)
+	return reasoningStyle.Render("▾ "+label) + "\n" + body
+}
 
-		// Only scroll if the focused message is not fully visible
-		if focusedMsgBottom > c.viewport.YOffset+c.viewport.Height {
-			// Message is below viewport - scroll down
-			c.viewport.YOffset = focusedMsgBottom - c.viewport.Height
-		} else if focusedMsgTop < c.viewport.YOffset {
-			// Message is above viewport - scroll up
-			c.viewport.YOffset = focusedMsgTop
+// toggleAllReasoning expands every reasoning section on the active path,
+// or collapses them all if they are already expanded
+func (c *ChatView) toggleAllReasoning() {
+	expand := false
+	for _, msg := range c.messages {
+		if msg.Reasoning != "" && !c.expanded[msg.ID] {
+			expand = true
+			break
 		}
-	} else {
-		// When not focused, stay at bottom for new messages
-		if totalHeight > c.viewport.Height {
-			c.viewport.YOffset = totalHeight - c.viewport.Height
+	}
+	for _, msg := range c.messages {
+		if msg.Reasoning != "" {
+			c.expanded[msg.ID] = expand
 		}
 	}
+	c.layoutDirty = true
+	c.updateContent()
+}
+
+// joinReasoning combines reasoning from separate sources
+func joinReasoning(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "\n\n")
 }
 
 // View renders the chat view
 func (c *ChatView) View() string {
-	return chatStyle.Render(c.viewport.View())
+	view := c.viewport.View()
+	if c.status != "" {
+		// Kept to one line, which is all the viewport leaves room for
+		view += "\n" + statusStyle.Width(c.width).Render(truncate(c.status, c.width-1))
+	}
+	return chatStyle.Render(view)
 }
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// maxCodeChoice is the highest code block number that can be picked with
+// a single digit
+const maxCodeChoice = 9
+
+// ansiSequence matches the escape sequences glamour draws with
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// terminalOutput is where the program draws, the standard output that
+// tea.NewProgram writes to by default
+var terminalOutput io.Writer = os.Stdout
+
+// copyCmd puts text on the clipboard and reports what was copied in the
+// status line. The system clipboard is used when there is one; over SSH,
+// or when it fails, the terminal is asked to copy with OSC52.
+func copyCmd(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		if text == "" {
+			return statusMsg{text: "Nothing to copy"}
+		}
+		if !remoteSession() && !clipboard.Unsupported {
+			if err := clipboard.WriteAll(text); err == nil {
+				return statusMsg{text: "Copied " + what}
+			}
+		}
+		if err := terminalCopy(text); err != nil {
+			return statusMsg{text: "Copy failed: " + err.Error()}
+		}
+		return statusMsg{text: "Copied " + what}
+	}
+}
+
+// remoteSession reports whether gochat runs over SSH, where the system
+// clipboard is that of the wrong machine
+func remoteSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// terminalCopy asks the terminal to copy text with OSC52. The sequence
+// goes to the file the program draws to in a single write. Writes to an
+// *os.File are serialized and the renderer sends each frame in one write,
+// so the sequence lands between two frames rather than inside one, and
+// the screen is left alone. tmux takes the sequence itself with
+// set-clipboard on; screen needs it wrapped to pass it on to the terminal
+// it runs in.
+func terminalCopy(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") == "" && (os.Getenv("STY") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen")) {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(terminalOutput)
+	return err
+}
+
+// plainText returns rendered markdown without colors or the margin and
+// padding glamour adds
+func plainText(rendered string) string {
+	lines := strings.Split(ansiSequence.ReplaceAllString(rendered, ""), "\n")
+	margin := -1
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+		if lines[i] == "" {
+			continue
+		}
+		indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+		if margin < 0 || indent < margin {
+			margin = indent
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= margin && margin > 0 {
+			lines[i] = line[margin:]
+		}
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// codeBlocks returns the contents of the fenced code blocks in markdown,
+// in order
+func codeBlocks(markdown string) []string {
+	source := []byte(markdown)
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+
+	var blocks []string
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		var code strings.Builder
+		lines := block.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			code.Write(segment.Value(source))
+		}
+		blocks = append(blocks, strings.TrimRight(code.String(), "\n"))
+		return ast.WalkSkipChildren, nil
+	})
+	return blocks
+}
+
+// copyMarkdown copies the message at index as it was written
+func (c *ChatView) copyMarkdown(index int) tea.Cmd {
+	return copyCmd(c.messages[index].Content, "message")
+}
+
+// copyText copies the message at index as it is shown, without markup
+func (c *ChatView) copyText(index int) tea.Cmd {
+	msg := c.messages[index]
+	return copyCmd(plainText(c.cache.markdown(msg, c.contentWidth())), "message text")
+}
+
+// copyCode copies the only code block of the message at index, or asks
+// which one to copy if there are several
+func (c *ChatView) copyCode(index int) tea.Cmd {
+	blocks := codeBlocks(c.messages[index].Content)
+	switch len(blocks) {
+	case 0:
+		return statusCmd("No code blocks in this message")
+	case 1:
+		return copyCmd(blocks[0], "code block")
+	}
+	c.codeChoice = min(len(blocks), maxCodeChoice)
+	c.setStatus(fmt.Sprintf("Copy which code block? 1–%d, esc to cancel", c.codeChoice))
+	return nil
+}
+
+// chooseCode copies the code block picked with a digit after copyCode
+// asked which one. It reports false if the key was not a choice, which
+// also abandons the question.
+func (c *ChatView) chooseCode(msg tea.KeyMsg) (tea.Cmd, bool) {
+	count := c.codeChoice
+	c.codeChoice = 0
+	c.setStatus("")
+
+	k := msg.String()
+	if k == "esc" {
+		return nil, true
+	}
+	if len(k) != 1 || k[0] < '1' || int(k[0]-'0') > count {
+		return nil, false
+	}
+	if c.focusIndex < 0 || c.focusIndex >= len(c.messages) {
+		return nil, true
+	}
+	n := int(k[0] - '0')
+	blocks := codeBlocks(c.messages[c.focusIndex].Content)
+	if n > len(blocks) {
+		return nil, true
+	}
+	return copyCmd(blocks[n-1], fmt.Sprintf("code block %d", n)), true
+}
+
+// copyAction runs a copy action on the focused message. Unlike the
+// actions in focusAction, copying is allowed while a reply streams.
+func (c *ChatView) copyAction(action func(index int) tea.Cmd) tea.Cmd {
+	if c.focusIndex < 0 || c.focusIndex >= len(c.messages) {
+		return nil
+	}
+	return action(c.focusIndex)
+}
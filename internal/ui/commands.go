@@ -0,0 +1,323 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+	"github.com/saiashirwad/gochat/internal/storage"
+)
+
+// Command is a slash command that is handled locally instead of being
+// sent to the LLM
+type Command struct {
+	Name        string
+	Args        string // Usage hint for arguments, e.g. "<name>"
+	Description string
+
+	// Run executes the command against the chat view
+	Run func(c *ChatView, args string) tea.Cmd
+
+	// Whether the command changes the conversation tree in a way the
+	// streaming reply cannot follow, so it must wait for the reply
+	ChangesTree bool
+
+	// Complete returns candidates for the command's argument
+	Complete func(cfg *config.Config) []string
+}
+
+// commandMsg asks the chat view to run a slash command
+type commandMsg struct {
+	command *Command
+	args    string
+}
+
+// showHelpMsg asks the input view to list every command
+type showHelpMsg struct{}
+
+// commands is the registry of slash commands
+var commands = []*Command{
+	{
+		Name:        "new",
+		Description: "Start a new conversation",
+		Run: func(c *ChatView, _ string) tea.Cmd {
+			c.NewChat()
+			return statusCmd("Started a new conversation")
+		},
+	},
+	{
+		Name:        "model",
+		Args:        "<name>",
+		Description: "Switch the model for this conversation",
+		Run:         (*ChatView).runModel,
+		Complete:    modelCandidates,
+	},
+	{
+		Name:        "profile",
+		Args:        "[name|-]",
+		Description: "Switch config profile (- for the plain llm settings)",
+		Run:         (*ChatView).runProfile,
+		Complete: func(cfg *config.Config) []string {
+			return cfg.ProfileNames()
+		},
+	},
+	{
+		Name:        "param",
+		Args:        "[<name> [value]]",
+		Description: "Show or set a sampling parameter for this conversation",
+		Run:         (*ChatView).runParam,
+		Complete: func(*config.Config) []string {
+			return chat.ParamNames
+		},
+	},
+	{
+		Name:        "system",
+		Args:        "<prompt>",
+		Description: "Set the system prompt (empty to remove)",
+		Run:         (*ChatView).runSystem,
+		ChangesTree: true,
+	},
+	{
+		Name:        "clear",
+		Description: "Remove all messages except the system prompt",
+		Run: func(c *ChatView, _ string) tea.Cmd {
+			c.clearMessages()
+			return statusCmd("Conversation cleared")
+		},
+	},
+	{
+		Name:        "save",
+		Args:        "[title]",
+		Description: "Save the conversation, optionally renaming it",
+		Run:         (*ChatView).runSave,
+	},
+	{
+		Name:        "export",
+		Args:        "[path]",
+		Description: "Export the conversation (.md, .json or .html)",
+		Run:         (*ChatView).runExport,
+	},
+	{
+		Name:        "retry",
+		Description: "Resend the last message",
+		Run: func(c *ChatView, _ string) tea.Cmd {
+			return c.retry()
+		},
+	},
+	{
+		Name:        "help",
+		Description: "List available commands",
+		Run: func(*ChatView, string) tea.Cmd {
+			return func() tea.Msg {
+				return showHelpMsg{}
+			}
+		},
+	},
+}
+
+// lookupCommand finds a command by name
+func lookupCommand(name string) *Command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// parseCommand splits slash command input into a name and arguments.
+// It reports false if the input is not a command.
+func parseCommand(input string) (string, string, bool) {
+	if !strings.HasPrefix(input, "/") || strings.HasPrefix(input, "//") {
+		return "", "", false
+	}
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	return name, strings.TrimSpace(args), true
+}
+
+// Usage returns the command as it is typed, including argument hints
+func (cmd *Command) Usage() string {
+	if cmd.Args == "" {
+		return "/" + cmd.Name
+	}
+	return "/" + cmd.Name + " " + cmd.Args
+}
+
+// runModel switches the model used for the rest of the conversation
+func (c *ChatView) runModel(args string) tea.Cmd {
+	if args == "" {
+		return statusCmd("Current model: " + c.llmClient.Model())
+	}
+	c.model = args
+	c.llmClient = c.llmClient.WithModel(args)
+	c.session.Model = args
+	return statusCmd("Switched model to " + args)
+}
+
+// runProfile switches the provider, model and parameters to a config
+// profile for the rest of the conversation
+func (c *ChatView) runProfile(args string) tea.Cmd {
+	if args == "" {
+		current := c.config.Profile()
+		if current == "" {
+			current = "none"
+		}
+		available := strings.Join(c.config.ProfileNames(), ", ")
+		if available == "" {
+			available = "none configured"
+		}
+		return statusCmd(fmt.Sprintf("Profile: %s (available: %s)", current, available))
+	}
+	if args == "-" {
+		args = ""
+	}
+	// Other views and replies in flight keep the config they were given
+	cfg := *c.config
+	if err := cfg.UseProfile(args); err != nil {
+		return statusCmd(err.Error())
+	}
+	c.config = &cfg
+	c.model = "" // The profile picks the model
+	c.llmClient = c.newClient()
+	c.session.Model = c.config.LLM.Model
+	if args == "" {
+		return statusCmd("Using the llm settings (" + c.config.LLM.Model + ")")
+	}
+	return statusCmd(fmt.Sprintf("Switched to profile %s (%s)", c.config.Profile(), c.config.LLM.Model))
+}
+
+// runParam shows the parameters in effect, or overrides one for this
+// conversation. Without a value the override is removed and the
+// configured default applies again.
+func (c *ChatView) runParam(args string) tea.Cmd {
+	if args == "" {
+		params := c.params().String()
+		if params == "" {
+			params = "provider defaults"
+		}
+		return statusCmd("Parameters: " + params)
+	}
+
+	name, value, _ := strings.Cut(args, " ")
+	var overrides chat.Params
+	if c.session.Params != nil {
+		overrides = *c.session.Params
+	}
+	if err := overrides.Set(name, value); err != nil {
+		return statusCmd(err.Error())
+	}
+	c.session.Params = &overrides
+	if overrides.IsZero() {
+		c.session.Params = nil
+	}
+
+	if strings.TrimSpace(value) == "" {
+		return tea.Batch(c.autosave(), statusCmd("Reset "+name+" to the default"))
+	}
+	return tea.Batch(c.autosave(), statusCmd("Set "+name+" for this conversation"))
+}
+
+// runSystem sets or removes the conversation's system prompt
+func (c *ChatView) runSystem(args string) tea.Cmd {
+	c.setSystemPrompt(args)
+	if args == "" {
+		return tea.Batch(c.autosave(), statusCmd("System prompt removed"))
+	}
+	return tea.Batch(c.autosave(), statusCmd("System prompt set"))
+}
+
+// runSave saves the conversation right away
+func (c *ChatView) runSave(args string) tea.Cmd {
+	if args != "" {
+		c.session.Title = args
+	}
+	c.syncSession()
+	if err := c.store.Save(c.session); err != nil {
+		return func() tea.Msg {
+			return errMsg{fmt.Errorf("save failed: %w", err)}
+		}
+	}
+	return statusCmd("Saved as " + c.session.ID)
+}
+
+// runExport writes the conversation to a file as Markdown, JSON or HTML,
+// picked by the extension of the path; Markdown if none is given
+func (c *ChatView) runExport(args string) tea.Cmd {
+	path := args
+	if path == "" {
+		path = "gochat-" + c.session.ID + ".md"
+	}
+	c.syncSession()
+	data, err := storage.Export(c.session, storage.FormatFromPath(path))
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		return func() tea.Msg {
+			return errMsg{fmt.Errorf("export failed: %w", err)}
+		}
+	}
+	return statusCmd("Exported to " + path)
+}
+
+// systemMessage returns the system prompt at the root of the active
+// path, or nil if there is none
+func (c *ChatView) systemMessage() *chat.Message {
+	if len(c.messages) == 0 || c.messages[0].Role != chat.RoleSystem {
+		return nil
+	}
+	return c.session.Get(c.messages[0].ID)
+}
+
+// setSystemPrompt replaces the system message at the root of the
+// conversation, which all branches share, removing it when prompt is empty
+func (c *ChatView) setSystemPrompt(prompt string) {
+	system := c.systemMessage()
+	switch {
+	case system != nil && prompt == "":
+		c.session.Remove(system.ID)
+	case system != nil:
+		system.Content = prompt
+		system.Timestamp = time.Now()
+	case prompt != "":
+		c.session.Prepend(chat.NewMessage(chat.RoleSystem, prompt))
+	}
+	c.refresh()
+}
+
+// clearMessages drops everything, every branch included, but the system
+// prompt
+func (c *ChatView) clearMessages() {
+	c.abandonStream()
+	system := c.systemMessage()
+	c.session.Conversation = chat.NewConversation()
+	if system != nil {
+		c.session.Append(*system)
+	}
+	c.editID = ""
+	c.focusIndex = 0
+	c.refresh()
+}
+
+// modelCandidates suggests the current model and any model used by a
+// saved chat
+func modelCandidates(cfg *config.Config) []string {
+	seen := map[string]bool{cfg.LLM.Model: true}
+	models := []string{cfg.LLM.Model}
+
+	chats, _ := storage.New(cfg.Storage.ChatsDir).List()
+	var others []string
+	for _, c := range chats {
+		if c.Model != "" && !seen[c.Model] {
+			seen[c.Model] = true
+			others = append(others, c.Model)
+		}
+	}
+	sort.Strings(others)
+	return append(models, others...)
+}
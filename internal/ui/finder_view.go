@@ -1,30 +1,69 @@
 package ui
 
 import (
+	"sort"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/saiashirwad/gochat/internal/config"
+	"github.com/saiashirwad/gochat/internal/storage"
 )
 
+// bodyMatchPenalty ranks matches in message bodies below title matches
+const bodyMatchPenalty = 24
+
+// snippetContext is how many runes to keep before a body match
+const snippetContext = 20
+
 // FinderView provides fuzzy search for chat history
 type FinderView struct {
 	config        *config.Config
+	store         *storage.Store
 	query         string
-	results       []string // This would be replaced with actual chat results
+	chats         []*storage.Chat
+	results       []finderResult
 	cursor        int
+	offset        int // Index of the first visible result
+	err           error
 	width, height int
 	style         lipgloss.Style
 }
 
+// finderResult is a saved chat that matched the query
+type finderResult struct {
+	chat       *storage.Chat
+	score      int
+	titlePos   []int  // Matched rune positions in the title
+	snippet    string // Matching line from a message, when the title did not match
+	snippetPos []int  // Matched rune positions in the snippet
+}
+
+// chatsLoadedMsg carries the saved chats read by the finder
+type chatsLoadedMsg struct {
+	chats []*storage.Chat
+	err   error
+}
+
+// closeFinderMsg is sent when the finder is dismissed without a selection
+type closeFinderMsg struct{}
+
 // NewFinderView creates a new finder view
 func NewFinderView(cfg *config.Config) *FinderView {
 	return &FinderView{
 		config: cfg,
-		style: lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("170")).
-			Padding(1),
-		results: []string{"Chat 1", "Chat 2", "Chat 3"}, // Placeholder results
+		store:  storage.New(cfg.Storage.ChatsDir),
+		style:  finderBoxStyle,
+	}
+}
+
+// ApplyConfig switches the view to a new configuration
+func (f *FinderView) ApplyConfig(cfg *config.Config) {
+	f.config = cfg
+	f.store = storage.New(f.config.Storage.ChatsDir)
+	f.style = finderBoxStyle
+	if f.width > 0 {
+		f.SetSize(f.width, f.height)
 	}
 }
 
@@ -37,43 +76,226 @@ func (f *FinderView) SetSize(width, height int) {
 
 // Init initializes the finder view
 func (f *FinderView) Init() tea.Cmd {
-	// In a real implementation, this would load chat history
-	return nil
+	f.query = ""
+	f.cursor = 0
+	f.offset = 0
+	store := f.store
+	return func() tea.Msg {
+		chats, err := store.List()
+		return chatsLoadedMsg{chats: chats, err: err}
+	}
 }
 
 // Update handles events for the finder view
 func (f *FinderView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case chatsLoadedMsg:
+		f.chats = msg.chats
+		f.err = msg.err
+		f.search()
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "up":
+		switch msg.Type {
+		case tea.KeyUp, tea.KeyCtrlP:
 			if f.cursor > 0 {
 				f.cursor--
 			}
-		case "down":
+		case tea.KeyDown, tea.KeyCtrlN:
 			if f.cursor < len(f.results)-1 {
 				f.cursor++
 			}
-		case "enter":
-			// Select the current chat
-			// Would dispatch a command to load the selected chat
+		case tea.KeyEnter:
+			if f.cursor < len(f.results) {
+				// Load the selected chat
+				selected := f.results[f.cursor].chat
+				return f, func() tea.Msg {
+					return loadChatMsg{chat: selected}
+				}
+			}
+		case tea.KeyEsc:
+			return f, func() tea.Msg {
+				return closeFinderMsg{}
+			}
+		case tea.KeyBackspace:
+			if runes := []rune(f.query); len(runes) > 0 {
+				f.query = string(runes[:len(runes)-1])
+				f.search()
+			}
+		case tea.KeyCtrlU:
+			f.query = ""
+			f.search()
+		case tea.KeySpace:
+			f.query += " "
+			f.search()
+		case tea.KeyRunes:
+			f.query += string(msg.Runes)
+			f.search()
 		}
 	}
+	f.scrollToCursor()
 	return f, nil
 }
 
+// search recomputes results for the current query
+func (f *FinderView) search() {
+	f.results = f.results[:0]
+	f.cursor = 0
+	f.offset = 0
+
+	query := strings.TrimSpace(f.query)
+	for _, c := range f.chats {
+		if query == "" {
+			f.results = append(f.results, finderResult{chat: c})
+			continue
+		}
+		if result, ok := matchChat(query, c); ok {
+			f.results = append(f.results, result)
+		}
+	}
+
+	// Chats arrive most recent first, so keep that order among equal scores
+	sort.SliceStable(f.results, func(i, j int) bool {
+		return f.results[i].score > f.results[j].score
+	})
+}
+
+// matchChat fuzzy-matches query against a chat's title and message bodies
+func matchChat(query string, c *storage.Chat) (finderResult, bool) {
+	result := finderResult{chat: c}
+	matched := false
+
+	if score, pos, ok := fuzzyMatch(query, c.DisplayTitle()); ok {
+		result.score, result.titlePos, matched = score, pos, true
+	}
+
+	// Bodies are matched line by line so a hit has to be reasonably close
+	// together rather than scattered over a whole reply
+	for _, msg := range c.Messages {
+		for _, line := range strings.Split(msg.Content, "\n") {
+			score, pos, ok := fuzzyMatch(query, line)
+			if !ok {
+				continue
+			}
+			score -= bodyMatchPenalty
+			if matched && score <= result.score {
+				continue
+			}
+			result.score, matched = score, true
+			result.titlePos = nil
+			result.snippet, result.snippetPos = snippet(line, pos)
+		}
+	}
+
+	return result, matched
+}
+
+// snippet trims a matching line to start shortly before the first match,
+// shifting match positions to suit
+func snippet(line string, positions []int) (string, []int) {
+	runes := []rune(line)
+	start := 0
+	if len(positions) > 0 && positions[0] > snippetContext {
+		start = positions[0] - snippetContext
+	}
+
+	shifted := make([]int, len(positions))
+	for i, pos := range positions {
+		shifted[i] = pos - start
+	}
+
+	text := string(runes[start:])
+	if start > 0 {
+		text = "…" + text
+		for i := range shifted {
+			shifted[i]++
+		}
+	}
+	return text, shifted
+}
+
+// scrollToCursor keeps the selected result inside the visible window
+func (f *FinderView) scrollToCursor() {
+	visible := f.visibleResults()
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	} else if f.cursor >= f.offset+visible {
+		f.offset = f.cursor - visible + 1
+	}
+}
+
+// visibleResults returns how many results fit on screen
+func (f *FinderView) visibleResults() int {
+	// Border, padding and the search line take up 7 rows; each result
+	// takes at most two
+	n := (f.height - 7) / 2
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
 // View renders the finder view
 func (f *FinderView) View() string {
-	var content string
-	content += "Search: " + f.query + "\n\n"
+	var content strings.Builder
+	content.WriteString("Search: " + f.query + "▏\n\n")
 
-	for i, result := range f.results {
+	switch {
+	case f.err != nil:
+		content.WriteString(finderDimStyle.Render("Error loading chats: "+f.err.Error()) + "\n")
+	case len(f.chats) == 0:
+		content.WriteString(finderDimStyle.Render("No saved chats yet") + "\n")
+	case len(f.results) == 0:
+		content.WriteString(finderDimStyle.Render("No matches") + "\n")
+	}
+
+	lineWidth := f.width - 10
+	end := f.offset + f.visibleResults()
+	if end > len(f.results) {
+		end = len(f.results)
+	}
+	for i := f.offset; i < end; i++ {
+		result := f.results[i]
+
+		prefix, base := "  ", lipgloss.NewStyle()
 		if i == f.cursor {
-			content += "> " + result + "\n"
+			prefix, base = "> ", finderCursorStyle
+		}
+
+		date := result.chat.UpdatedAt.Format("2006-01-02 15:04")
+		title := highlight(truncate(result.chat.DisplayTitle(), lineWidth-len(date)-2), result.titlePos, base)
+		content.WriteString(base.Render(prefix) + title + "  " + finderDimStyle.Render(date) + "\n")
+
+		if result.snippet != "" {
+			text := highlight(truncate(result.snippet, lineWidth-2), result.snippetPos, finderDimStyle)
+			content.WriteString("    " + text + "\n")
+		}
+	}
+
+	return f.style.Render(content.String())
+}
+
+// highlight renders text with the runes at positions emphasized
+func highlight(text string, positions []int, base lipgloss.Style) string {
+	matched := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		matched[pos] = true
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		if matched[i] {
+			b.WriteString(finderMatchStyle.Render(string(r)))
 		} else {
-			content += "  " + result + "\n"
+			b.WriteString(base.Render(string(r)))
 		}
 	}
+	return b.String()
+}
 
-	return f.style.Render(content)
+// truncate shortens text to at most width runes
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if width < 1 || len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
 }
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"unicode"
+)
+
+// Fuzzy scoring weights
+const (
+	scoreMatch       = 16 // Each matched rune
+	scoreGapPenalty  = 1  // Each unmatched rune inside the match span
+	bonusConsecutive = 8  // Matched rune directly follows the previous one
+	bonusBoundary    = 10 // Matched rune starts a word
+	bonusFirstRune   = 6  // Match starts at the very beginning of the text
+)
+
+// fuzzyMatch reports whether the runes of pattern appear in text in
+// order, ignoring case. On success it returns a score (higher is better)
+// and the rune positions in text that matched. Of all possible matches the
+// tightest, best-scoring one is chosen.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	p := []rune(toLower(pattern))
+	t := []rune(toLower(text))
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	if len(p) > len(t) {
+		return 0, nil, false
+	}
+
+	orig := []rune(text)
+	bestScore := 0
+	var bestPos []int
+	found := false
+
+	for start := 0; start <= len(t)-len(p); start++ {
+		if t[start] != p[0] {
+			continue
+		}
+
+		// Scan forward for the first complete match from this start
+		end := -1
+		pi := 0
+		for ti := start; ti < len(t); ti++ {
+			if t[ti] == p[pi] {
+				pi++
+				if pi == len(p) {
+					end = ti
+					break
+				}
+			}
+		}
+		if end < 0 {
+			// No complete match from here means none from any later start
+			break
+		}
+
+		// Scan backward from the end to find the tightest window
+		positions := make([]int, len(p))
+		pi = len(p) - 1
+		for ti := end; ti >= start && pi >= 0; ti-- {
+			if t[ti] == p[pi] {
+				positions[pi] = ti
+				pi--
+			}
+		}
+
+		score := scoreWindow(orig, positions)
+		if !found || score > bestScore {
+			bestScore, bestPos, found = score, positions, true
+		}
+
+		// Later starts inside this window cannot be tighter
+		start = positions[0]
+	}
+
+	return bestScore, bestPos, found
+}
+
+// scoreWindow scores a set of matched rune positions in text
+func scoreWindow(text []rune, positions []int) int {
+	score := 0
+	for i, pos := range positions {
+		score += scoreMatch
+		if i > 0 {
+			if pos == positions[i-1]+1 {
+				score += bonusConsecutive
+			} else {
+				score -= (pos - positions[i-1] - 1) * scoreGapPenalty
+			}
+		}
+		if pos == 0 {
+			score += bonusFirstRune
+		}
+		if pos == 0 || isWordBoundary(text[pos-1]) {
+			score += bonusBoundary
+		}
+	}
+	return score
+}
+
+// isWordBoundary reports whether r separates words
+func isWordBoundary(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// toLower lowercases s rune by rune so rune positions stay aligned with
+// the original string
+func toLower(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
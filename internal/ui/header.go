@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/saiashirwad/gochat/internal/chat"
+)
+
+// headerTickInterval is how often relative times in headers are updated
+const headerTickInterval = 30 * time.Second
+
+// headerTickMsg asks the chat view to refresh relative times
+type headerTickMsg struct{}
+
+// headerTick schedules the next refresh of relative times
+func headerTick() tea.Cmd {
+	return tea.Tick(headerTickInterval, func(time.Time) tea.Msg {
+		return headerTickMsg{}
+	})
+}
+
+// messageHeader returns the header line of a message: who it is from and
+// its state, followed with ui.show_timestamp by when it arrived, the
+// model, how long it took and the tokens it used
+func (c *ChatView) messageHeader(msg chat.Message, streaming bool) string {
+	label := "LLM Message"
+	switch msg.Role {
+	case chat.RoleUser:
+		label = "My message"
+	case chat.RoleSystem:
+		label = "System prompt"
+	}
+	if streaming {
+		label += " (streaming…)"
+	} else if msg.Cancelled {
+		label += " (cancelled)"
+	} else if msg.Failed {
+		label += " (failed)"
+	}
+	label += c.branchLabel(msg.ID)
+	if truncation, ok := msg.Metadata[metaTruncation].(string); ok {
+		label += " (" + truncation + ")"
+	}
+	if msg.ID == c.editID {
+		label += " (editing)"
+	}
+
+	if !c.config.UI.ShowTimestamp {
+		return headerStyle.Render(label)
+	}
+
+	// Leave out the least important details that do not fit
+	info := messageInfo(msg, streaming, c.config.UI.TimestampFormat, time.Now())
+	for len(info) > 0 && lipgloss.Width(label+" · "+strings.Join(info, " · ")) > c.contentWidth()-2 {
+		info = info[:len(info)-1]
+	}
+	if len(info) == 0 {
+		return headerStyle.Render(label)
+	}
+	return headerStyle.Render(label + headerInfoStyle.Render(" · "+strings.Join(info, " · ")))
+}
+
+// messageInfo returns the details shown after a message's label, most
+// important first
+func messageInfo(msg chat.Message, streaming bool, format string, now time.Time) []string {
+	if msg.Local() {
+		return nil
+	}
+
+	var info []string
+	if !streaming && !msg.Timestamp.IsZero() {
+		if strings.EqualFold(format, "absolute") {
+			info = append(info, absoluteTime(msg.Timestamp, now))
+		} else {
+			info = append(info, relativeTime(msg.Timestamp, now))
+		}
+	}
+	if msg.Role == chat.RoleAssistant && msg.Model != "" {
+		info = append(info, msg.Model)
+	}
+	if streaming {
+		return info
+	}
+	if msg.Timing != nil && msg.Timing.Total > 0 {
+		info = append(info, roundDuration(msg.Timing.Total))
+	}
+	if msg.Usage != nil && (msg.Usage.PromptTokens > 0 || msg.Usage.CompletionTokens > 0) {
+		info = append(info, fmt.Sprintf("%d → %d tokens", msg.Usage.PromptTokens, msg.Usage.CompletionTokens))
+	}
+	return info
+}
+
+// relativeTime describes t as time before now, switching to a date after
+// a week
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	default:
+		return absoluteTime(t, now)
+	}
+}
+
+// absoluteTime formats t with as much of the date as differs from now
+func absoluteTime(t, now time.Time) string {
+	t = t.Local()
+	switch {
+	case t.Year() == now.Year() && t.YearDay() == now.YearDay():
+		return t.Format("15:04")
+	case t.Year() == now.Year():
+		return t.Format("Jan 2 15:04")
+	default:
+		return t.Format("Jan 2 2006 15:04")
+	}
+}
+
+// roundDuration formats d to a tenth of a second, or in milliseconds
+// below a second
+func roundDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
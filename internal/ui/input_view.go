@@ -1,55 +1,186 @@
 package ui
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 
-	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 	"github.com/saiashirwad/gochat/internal/config"
 )
 
+// defaultInputMaxRows is used when ui.input_max_rows is unset
+const defaultInputMaxRows = 6
+
+// maxSuggestions caps the rows of the completion popup
+const maxSuggestions = 8
+
 // userInputMsg is sent when the user submits a message
 type userInputMsg struct {
 	input string
 }
 
+// editorFinishedMsg carries the draft back from the external editor
+type editorFinishedMsg struct {
+	content string
+	err     error
+}
+
+// InputKeyMap defines the keybindings for the input view
+type InputKeyMap struct {
+	Send     key.Binding
+	Newline  key.Binding
+	Editor   key.Binding
+	Complete key.Binding
+	Previous key.Binding
+}
+
+// DefaultInputKeyMap returns the default input keybindings
+func DefaultInputKeyMap() InputKeyMap {
+	return InputKeyMap{
+		Send: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("Enter", "send"),
+		),
+		Newline: key.NewBinding(
+			key.WithKeys("alt+enter", "ctrl+j"),
+			key.WithHelp("Alt+Enter", "new line"),
+		),
+		Editor: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("Ctrl+o", "edit in $EDITOR"),
+		),
+		Complete: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("Tab", "complete command"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("Shift+Tab", "previous completion"),
+		),
+	}
+}
+
 // InputView handles user input
 type InputView struct {
-	config    *config.Config
-	textInput textinput.Model
-	width     int
+	config   *config.Config
+	textarea textarea.Model
+	width    int
+	keys     InputKeyMap
+
+	// Slash command completion shown in a popup above the input
+	suggestions    []suggestion
+	selected       int    // Index of the applied suggestion, -1 for none
+	completionBase string // Draft the suggestions were computed from
+	showHelp       bool
+
+	// Whether the draft is an edit of an earlier message
+	editing bool
+}
+
+// suggestion is a completion candidate for slash command input
+type suggestion struct {
+	value       string // Full input after applying the suggestion
+	label       string
+	description string
 }
 
 // NewInputView creates a new input view
 func NewInputView(cfg *config.Config) *InputView {
-	ti := textinput.New()
-	ti.Placeholder = "Type your message and press Enter..."
-	ti.CharLimit = 4096 // Reasonable limit for LLM context
-	ti.Width = 40       // Will be adjusted by SetWidth
-	ti.Focus()
-
-	// Style the text input
-	ti.Prompt = ""
-	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-	ti.PromptStyle = lipgloss.NewStyle().Background(lipgloss.Color("233"))
-	ti.PlaceholderStyle = ti.TextStyle.Copy().Foreground(lipgloss.Color("240"))
+	keys := configInputKeyMap(cfg)
+
+	ta := textarea.New()
+	ta.Placeholder = "Type your message and press Enter..."
+	ta.CharLimit = 0 // The model's context window is the real limit
+	ta.MaxHeight = 0 // Rows are capped by SetHeight instead
+	ta.ShowLineNumbers = false
+	ta.EndOfBufferCharacter = ' '
+	ta.KeyMap.InsertNewline = keys.Newline
+	ta.SetHeight(1)
+	ta.Focus()
+
+	ta.Prompt = ""
+	styleTextarea(&ta)
 
 	return &InputView{
-		config:    cfg,
-		textInput: ti,
+		config:   cfg,
+		textarea: ta,
+		keys:     keys,
+		selected: -1,
 	}
 }
 
 // SetWidth updates the width of the input view
 func (i *InputView) SetWidth(width int) {
 	i.width = width
-	i.textInput.Width = width
+	i.textarea.SetWidth(width)
+	i.updateHeight()
+}
+
+// ApplyConfig switches the view to a new configuration
+func (i *InputView) ApplyConfig(cfg *config.Config) {
+	i.config = cfg
+	styleTextarea(&i.textarea)
+	i.keys = configInputKeyMap(i.config)
+	i.textarea.KeyMap.InsertNewline = i.keys.Newline
+	i.updateHeight()
+}
+
+// styleTextarea colors the text area in the current theme
+func styleTextarea(ta *textarea.Model) {
+	ta.FocusedStyle.Text = inputTextStyle
+	ta.FocusedStyle.CursorLine = ta.FocusedStyle.Text
+	ta.FocusedStyle.Placeholder = placeholderStyle
+	ta.BlurredStyle.Text = ta.FocusedStyle.Text.Copy().Faint(true)
+	ta.BlurredStyle.CursorLine = ta.BlurredStyle.Text
+	ta.BlurredStyle.Placeholder = ta.FocusedStyle.Placeholder
+}
+
+// Height returns the number of rows the input view currently occupies,
+// including the completion popup
+func (i *InputView) Height() int {
+	return i.textarea.Height() + i.popupHeight()
+}
+
+// maxRows returns the configured row limit for the input area
+func (i *InputView) maxRows() int {
+	if i.config.UI.InputMaxRows > 0 {
+		return i.config.UI.InputMaxRows
+	}
+	return defaultInputMaxRows
+}
+
+// updateHeight grows or shrinks the text area to fit the draft, counting
+// soft-wrapped lines, up to the configured maximum
+func (i *InputView) updateHeight() {
+	width := i.textarea.Width()
+	rows := 0
+	for _, line := range strings.Split(i.textarea.Value(), "\n") {
+		lineRows := 1
+		if w := runewidth.StringWidth(line); width > 0 && w >= width {
+			lineRows = w/width + 1
+		}
+		rows += lineRows
+	}
+
+	if max := i.maxRows(); rows > max {
+		rows = max
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if rows != i.textarea.Height() {
+		i.textarea.SetHeight(rows)
+	}
 }
 
 // Init initializes the input view
 func (i *InputView) Init() tea.Cmd {
-	return textinput.Blink
+	return textarea.Blink
 }
 
 // Update handles events for the input view
@@ -58,37 +189,286 @@ func (i *InputView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEsc:
-			return i, func() tea.Msg {
-				return focusChatsMsg{}
+		if !i.textarea.Focused() {
+			return i, nil
+		}
+		switch {
+		case msg.Type == tea.KeyEsc:
+			// Close the popup first, then leave the input
+			if i.popupHeight() > 0 {
+				i.closePopup()
+				return i, nil
 			}
-		case tea.KeyEnter:
-			if input := strings.TrimSpace(i.textInput.Value()); input != "" {
-				oldInput := input
-				i.textInput.Reset()
+			if i.editing {
+				i.editing = false
+				i.textarea.Reset()
+				i.updateHeight()
 				return i, func() tea.Msg {
-					return userInputMsg{input: oldInput}
+					return editCancelMsg{}
 				}
 			}
+			i.Blur()
+			return i, func() tea.Msg {
+				return focusChatsMsg{}
+			}
+		case key.Matches(msg, i.keys.Send):
+			if input := strings.TrimSpace(i.textarea.Value()); input != "" {
+				return i, i.submit(input)
+			}
+			return i, nil
+		case key.Matches(msg, i.keys.Editor):
+			return i, openEditorCmd(i.textarea.Value())
+		case key.Matches(msg, i.keys.Complete):
+			if i.cycleSuggestion(1) {
+				return i, nil
+			}
+		case key.Matches(msg, i.keys.Previous):
+			if i.cycleSuggestion(-1) {
+				return i, nil
+			}
 		}
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			return i, func() tea.Msg {
+				return errMsg{fmt.Errorf("editor: %w", msg.err)}
+			}
+		}
+		i.textarea.SetValue(msg.content)
+		i.updateSuggestions()
+		i.updateHeight()
+		return i, nil
+
+	case showHelpMsg:
+		i.showHelp = true
+		return i, nil
+
+	case editMessageMsg:
+		i.editing = true
+		i.closePopup()
+		i.textarea.SetValue(msg.content)
+		i.updateHeight()
+		i.Focus()
+		return i, nil
 	}
 
-	i.textInput, cmd = i.textInput.Update(msg)
+	before := i.textarea.Value()
+	i.textarea, cmd = i.textarea.Update(msg)
+	if i.textarea.Value() != before {
+		i.showHelp = false
+		i.updateSuggestions()
+	}
+	i.updateHeight()
 	return i, cmd
 }
 
+// submit sends the draft as a message, or dispatches it if it is a slash
+// command. Unknown commands keep the draft so it can be fixed.
+func (i *InputView) submit(input string) tea.Cmd {
+	var msg tea.Msg = userInputMsg{input: input}
+	if i.editing {
+		// Edits are resent as-is, even if they look like a command
+		i.editing = false
+		msg = editSubmitMsg{input: input}
+	} else if name, args, ok := parseCommand(input); ok {
+		cmd := lookupCommand(name)
+		if cmd == nil {
+			return statusCmd(fmt.Sprintf("Unknown command /%s, type /help for a list", name))
+		}
+		msg = commandMsg{command: cmd, args: args}
+	} else if strings.HasPrefix(input, "//") {
+		// A doubled slash sends a message that starts with one
+		msg = userInputMsg{input: input[1:]}
+	}
+
+	i.textarea.Reset()
+	i.closePopup()
+	i.updateHeight()
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// updateSuggestions recomputes completions for the current draft
+func (i *InputView) updateSuggestions() {
+	i.suggestions = nil
+	i.selected = -1
+	i.completionBase = i.textarea.Value()
+
+	draft := i.completionBase
+	if !strings.HasPrefix(draft, "/") || strings.HasPrefix(draft, "//") || strings.Contains(draft, "\n") {
+		return
+	}
+
+	name, arg, hasArgs := strings.Cut(strings.TrimPrefix(draft, "/"), " ")
+	if !hasArgs {
+		// Complete the command name
+		for _, cmd := range commands {
+			if strings.HasPrefix(cmd.Name, name) {
+				i.suggestions = append(i.suggestions, suggestion{
+					value:       "/" + cmd.Name + " ",
+					label:       cmd.Usage(),
+					description: cmd.Description,
+				})
+			}
+		}
+		return
+	}
+
+	// Complete the command's argument
+	cmd := lookupCommand(name)
+	if cmd == nil || cmd.Complete == nil {
+		return
+	}
+	for _, candidate := range cmd.Complete(i.config) {
+		if strings.HasPrefix(candidate, arg) {
+			i.suggestions = append(i.suggestions, suggestion{
+				value: "/" + cmd.Name + " " + candidate,
+				label: candidate,
+			})
+		}
+	}
+}
+
+// cycleSuggestion applies the next or previous suggestion to the draft.
+// It reports false if there is nothing to complete.
+func (i *InputView) cycleSuggestion(step int) bool {
+	if len(i.suggestions) == 0 {
+		return false
+	}
+	n := len(i.suggestions)
+	if i.selected < 0 && step < 0 {
+		i.selected = n - 1
+	} else {
+		i.selected = ((i.selected+step)%n + n) % n
+	}
+	i.textarea.SetValue(i.suggestions[i.selected].value)
+	i.updateHeight()
+	return true
+}
+
+// closePopup hides completions and help
+func (i *InputView) closePopup() {
+	i.suggestions = nil
+	i.selected = -1
+	i.showHelp = false
+}
+
+// popupLines returns the rows of the popup shown above the input
+func (i *InputView) popupLines() []string {
+	if i.showHelp {
+		lines := make([]string, 0, len(commands)+4)
+		for _, cmd := range commands {
+			lines = append(lines, popupStyle.Render(fmt.Sprintf("%-18s", cmd.Usage()))+popupDescStyle.Render(cmd.Description))
+		}
+		for _, binding := range []key.Binding{i.keys.Send, i.keys.Newline, i.keys.Editor, i.keys.Complete} {
+			help := binding.Help()
+			lines = append(lines, popupStyle.Render(fmt.Sprintf("%-18s", help.Key))+popupDescStyle.Render(help.Desc))
+		}
+		return lines
+	}
+
+	// Keep the selected suggestion inside the visible window
+	start := 0
+	if i.selected >= maxSuggestions {
+		start = i.selected - maxSuggestions + 1
+	}
+	end := start + maxSuggestions
+	if end > len(i.suggestions) {
+		end = len(i.suggestions)
+	}
+
+	var lines []string
+	for idx := start; idx < end; idx++ {
+		s := i.suggestions[idx]
+		style := popupStyle
+		if idx == i.selected {
+			style = popupSelectedStyle
+		}
+		line := style.Render(fmt.Sprintf("%-18s", s.label))
+		if s.description != "" {
+			line += popupDescStyle.Render(s.description)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// popupHeight returns the number of rows taken by the popup
+func (i *InputView) popupHeight() int {
+	if i.showHelp {
+		return len(commands) + 4
+	}
+	if len(i.suggestions) > maxSuggestions {
+		return maxSuggestions
+	}
+	return len(i.suggestions)
+}
+
+// openEditorCmd suspends the program and opens the draft in $VISUAL or
+// $EDITOR, reading it back once the editor exits
+func openEditorCmd(draft string) tea.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "gochat-*.md")
+	if err != nil {
+		return func() tea.Msg {
+			return editorFinishedMsg{err: err}
+		}
+	}
+	path := f.Name()
+	_, err = f.WriteString(draft)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg {
+			return editorFinishedMsg{err: err}
+		}
+	}
+
+	// Allow editors configured with arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorFinishedMsg{err: err}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return editorFinishedMsg{err: err}
+		}
+		return editorFinishedMsg{content: strings.TrimRight(string(data), "\n")}
+	})
+}
+
 // View renders the input view
 func (i *InputView) View() string {
-	return i.textInput.View()
+	if lines := i.popupLines(); len(lines) > 0 {
+		return strings.Join(lines, "\n") + "\n" + i.textarea.View()
+	}
+	return i.textarea.View()
+}
+
+// Focused reports whether the input view has focus
+func (i *InputView) Focused() bool {
+	return i.textarea.Focused()
 }
 
 // Focus sets the input view as focused
 func (i *InputView) Focus() {
-	i.textInput.Focus()
+	i.textarea.Focus()
 }
 
 // Blur removes focus from the input view
 func (i *InputView) Blur() {
-	i.textInput.Blur()
+	i.textarea.Blur()
 }
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// configKeyMap returns the chat view keybindings with the config's
+// overrides applied
+func configKeyMap(cfg *config.Config) KeyMap {
+	keys := DefaultKeyMap()
+	rebind(keys.actions(), cfg.Keys)
+	return keys
+}
+
+// configInputKeyMap returns the input keybindings with the config's
+// overrides applied
+func configInputKeyMap(cfg *config.Config) InputKeyMap {
+	keys := DefaultInputKeyMap()
+	rebind(keys.actions(), cfg.Keys)
+	return keys
+}
+
+// actions maps the names used under keys in the config to the chat view
+// bindings. Keep in step with config.KeyActions.
+func (k *KeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"page_up":              &k.PageUp,
+		"page_down":            &k.PageDown,
+		"half_up":              &k.HalfUp,
+		"half_down":            &k.HalfDown,
+		"up":                   &k.Up,
+		"down":                 &k.Down,
+		"top":                  &k.Top,
+		"bottom":               &k.Bottom,
+		"cancel":               &k.Cancel,
+		"retry":                &k.Retry,
+		"regenerate":           &k.Regenerate,
+		"edit":                 &k.Edit,
+		"delete":               &k.Delete,
+		"prev_branch":          &k.PrevBranch,
+		"next_branch":          &k.NextBranch,
+		"toggle_reasoning":     &k.ToggleReasoning,
+		"toggle_all_reasoning": &k.ToggleAllReasoning,
+		"copy":                 &k.CopyMarkdown,
+		"copy_text":            &k.CopyText,
+		"copy_code":            &k.CopyCode,
+	}
+}
+
+// actions maps the names used under keys in the config to the input
+// bindings
+func (k *InputKeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"send":     &k.Send,
+		"newline":  &k.Newline,
+		"editor":   &k.Editor,
+		"complete": &k.Complete,
+		"previous": &k.Previous,
+	}
+}
+
+// rebind replaces the keys of each action configured in overrides, and
+// the key shown in its help. Actions that are not in bindings are left
+// to the other key map.
+func rebind(bindings map[string]*key.Binding, overrides map[string][]string) {
+	for action, keys := range overrides {
+		binding, ok := bindings[action]
+		if !ok || len(keys) == 0 {
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/theme"
+)
+
+// renderCache keeps each message as it was last drawn, so that updates
+// only re-render the messages whose content, state, width or theme
+// changed. A message is drawn in three layers that change at different
+// rates: the Markdown of its content, the body in its border, and the
+// header line above it, which shows times that change on their own. The
+// body is cached on the fields that only change with the message, and
+// its height is all the layout needs.
+type renderCache struct {
+	entries map[string]*renderEntry // By message ID
+}
+
+// renderEntry is the cached drawing of one message
+type renderEntry struct {
+	content   stringHash
+	reasoning stringHash
+
+	markdownKey renderKey
+	markdown    string // Markdown rendering of the content
+
+	bodyKey    bodyKey
+	body       string // Reasoning and content in the message border
+	bodyHeight int
+	bodyDrawn  int // Counts drawings of body, to tell them apart
+
+	blockKey blockKey
+	block    string // Header and body, aligned in the window
+}
+
+// renderKey identifies the inputs a Markdown rendering was made from
+type renderKey struct {
+	hash  uint64 // Content
+	width int
+	theme *theme.Theme
+}
+
+// bodyKey identifies the inputs a message body was drawn from
+type bodyKey struct {
+	markdown  renderKey
+	reasoning uint64
+	kind      string // Border: user, assistant, system or focused
+	thinking  bool   // Reasoning still streaming in
+	empty     bool   // Nothing to show yet, drawn as a placeholder
+	expanded  bool
+}
+
+// blockKey identifies the inputs a finished message was drawn from
+type blockKey struct {
+	header string
+	body   int // bodyDrawn
+	width  int
+	align  string
+}
+
+// newRenderCache creates an empty cache
+func newRenderCache() *renderCache {
+	return &renderCache{entries: make(map[string]*renderEntry)}
+}
+
+// entry returns the cache entry for a message, creating it if needed
+func (r *renderCache) entry(id string) *renderEntry {
+	e, ok := r.entries[id]
+	if !ok {
+		e = &renderEntry{}
+		r.entries[id] = e
+	}
+	return e
+}
+
+// prune forgets messages that are no longer shown
+func (r *renderCache) prune(messages []chat.Message) {
+	if len(r.entries) <= len(messages) {
+		return
+	}
+	shown := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		shown[msg.ID] = true
+	}
+	for id := range r.entries {
+		if !shown[id] {
+			delete(r.entries, id)
+		}
+	}
+}
+
+// stringHash remembers the hash of the last string it was given
+type stringHash struct {
+	s    string
+	hash uint64
+}
+
+// of returns the hash of s. It is only computed again when s is a
+// different string: an unchanged message shares its strings with the
+// previous call, which compare equal without reading them.
+func (h *stringHash) of(s string) uint64 {
+	if s != h.s || h.hash == 0 {
+		h.s = s
+		h.hash = hashStrings(s)
+	}
+	return h.hash
+}
+
+// markdown returns the rendered content of a message at the current
+// width and theme
+func (r *renderCache) markdown(msg chat.Message, width int) string {
+	e := r.entry(msg.ID)
+	key := renderKey{hash: e.content.of(msg.Content), width: width, theme: currentTheme}
+	if e.markdownKey == key {
+		return e.markdown
+	}
+
+	rendered, err := markdownRenderer.Render(msg.Content)
+	if err != nil {
+		rendered = msg.Content // Fallback to plain text if markdown rendering fails
+	}
+	e.markdownKey = key
+	e.markdown = strings.TrimSpace(rendered) // Remove extra newlines from glamour
+	return e.markdown
+}
+
+// hashStrings returns a hash of parts, keeping their boundaries
+func hashStrings(parts ...string) uint64 {
+	h := fnv.New64a()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// messageStyle returns the border style of the i-th message and the
+// name it is cached under
+func (c *ChatView) messageStyle(i int, msg chat.Message) (lipgloss.Style, string) {
+	switch {
+	case c.focusActive && i == c.focusIndex:
+		return focusedMessageStyle, "focused"
+	case msg.Role == chat.RoleUser:
+		return userMessageStyle, "user"
+	case msg.Role == chat.RoleSystem:
+		return systemMessageStyle, "system"
+	default:
+		return llmMessageStyle, "assistant"
+	}
+}
+
+// renderBody draws the reasoning and content of the i-th message in its
+// border, reusing the previous drawing when none of its inputs changed
+func (c *ChatView) renderBody(i int, msg chat.Message) *renderEntry {
+	streaming := c.stream != nil && msg.ID == c.stream.replyID
+	style, kind := c.messageStyle(i, msg)
+
+	e := c.cache.entry(msg.ID)
+	key := bodyKey{
+		markdown: renderKey{hash: e.content.of(msg.Content), width: c.contentWidth(), theme: currentTheme},
+		kind:     kind,
+		thinking: streaming && msg.Content == "",
+		// Hold the place of a reply that has nothing to show yet
+		empty:    msg.Content == "" && ((streaming && msg.Reasoning == "") || (!streaming && msg.Cancelled)),
+		expanded: c.expanded[msg.ID],
+	}
+	if msg.Reasoning != "" {
+		key.reasoning = e.reasoning.of(msg.Reasoning)
+	}
+	if e.bodyKey == key && e.bodyDrawn > 0 {
+		return e
+	}
+
+	rendered := c.cache.markdown(msg, c.contentWidth())
+	if key.empty {
+		rendered = "…"
+	}
+
+	// Put reasoning between the header and the answer
+	if msg.Reasoning != "" {
+		reasoning := c.renderReasoning(msg, key.thinking)
+		if rendered != "" {
+			rendered = reasoning + "\n" + rendered
+		} else {
+			rendered = reasoning
+		}
+	}
+
+	e.bodyKey = key
+	e.body = style.Render(rendered)
+	e.bodyHeight = strings.Count(e.body, "\n") + 1
+	e.bodyDrawn++
+	return e
+}
+
+// messageHeight returns the lines the i-th message takes: its header,
+// which is always one line, and its body
+func (c *ChatView) messageHeight(i int, msg chat.Message) int {
+	return 1 + c.renderBody(i, msg).bodyHeight
+}
+
+// renderMessage returns the i-th message drawn with its header,
+// reasoning and border, and its height in lines. The previous drawing is
+// reused when nothing that affects it has changed.
+func (c *ChatView) renderMessage(i int, msg chat.Message) (string, int) {
+	streaming := c.stream != nil && msg.ID == c.stream.replyID
+	e := c.renderBody(i, msg)
+	height := 1 + e.bodyHeight
+
+	key := blockKey{
+		header: c.messageHeader(msg, streaming),
+		body:   e.bodyDrawn,
+		width:  c.width,
+		align:  c.config.UI.Align,
+	}
+	if e.blockKey == key {
+		return e.block, height
+	}
+
+	// The header is drawn in the same border as the body, cut to the one
+	// line the layout counted for it
+	style, _ := c.messageStyle(i, msg)
+	header := style.Copy().MaxHeight(1).Render(key.header)
+
+	e.blockKey = key
+	e.block = c.align(header + "\n" + e.body)
+	return e.block, height
+}
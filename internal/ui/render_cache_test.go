@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saiashirwad/gochat/internal/chat"
+	"github.com/saiashirwad/gochat/internal/config"
+)
+
+// benchChatView returns a chat view showing a conversation of n messages
+// with relative timestamps in their headers
+func benchChatView(tb testing.TB, n int) *ChatView {
+	tb.Helper()
+	cfg := &config.Config{}
+	cfg.Storage.ChatsDir = tb.TempDir()
+	cfg.UI.ShowTimestamp = true
+	cfg.UI.TimestampFormat = "relative"
+
+	c := NewChatView(cfg)
+	start := time.Now().Add(-time.Duration(n) * time.Minute)
+	for i := 0; i < n; i++ {
+		role, content := chat.RoleUser, fmt.Sprintf("Question %d: how do I reverse a slice in **Go**?", i)
+		if i%2 == 1 {
+			role = chat.RoleAssistant
+			content = fmt.Sprintf("Answer %d. Swap from both ends:\n\n```go\nfor i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {\n\ts[i], s[j] = s[j], s[i]\n}\n```\n\n- works in place\n- needs no allocation", i)
+		}
+		msg := chat.NewMessage(role, content)
+		msg.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		c.session.Append(msg)
+	}
+	c.SetSize(100, 40)
+	c.refresh()
+	return c
+}
+
+// BenchmarkRenderLayout lays out a long conversation again, as a resize
+// back to the same width, a reload or a new message does
+func BenchmarkRenderLayout(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := benchChatView(b, 500)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if !cached {
+					c.cache = newRenderCache()
+				}
+				c.refresh()
+			}
+		})
+	}
+}
+
+// BenchmarkRenderHeaderTick redraws a long conversation when relative
+// times in the headers are due to change
+func BenchmarkRenderHeaderTick(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := benchChatView(b, 500)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if !cached {
+					c.cache = newRenderCache()
+				}
+				c.Update(headerTickMsg{})
+			}
+		})
+	}
+}
+
+func TestRenderCacheMatchesUncached(t *testing.T) {
+	c := benchChatView(t, 20)
+	c.focusActive = true
+	c.focusIndex = 5
+	c.expanded[c.messages[3].ID] = true
+	c.refresh()
+	cached := c.viewport.View()
+
+	c.cache = newRenderCache()
+	c.refresh()
+	if uncached := c.viewport.View(); cached != uncached {
+		t.Errorf("cached drawing differs from a fresh one:\n%s\n---\n%s", cached, uncached)
+	}
+	for i, height := range c.heights {
+		block, _ := c.renderMessage(i, c.messages[i])
+		if got := strings.Count(block, "\n") + 1; got != height {
+			t.Errorf("message %d measured at %d lines, drawn in %d", i, height, got)
+		}
+	}
+}
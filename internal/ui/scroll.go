@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+)
+
+// mouseWheelLines is how far one step of the mouse wheel scrolls
+const mouseWheelLines = 3
+
+// The chat view keeps its own layout rather than handing the whole
+// conversation to the viewport: the height of every message is measured
+// once, and only the messages near the visible lines are drawn. Offsets
+// are in lines from the top of the conversation.
+
+// measure records the height and position of every message. Heights
+// come from the cached bodies, so only messages that changed are drawn.
+func (c *ChatView) measure() {
+	n := len(c.messages)
+	c.heights = make([]int, n)
+	c.tops = make([]int, n+1)
+	for i, msg := range c.messages {
+		height := c.messageHeight(i, msg)
+		c.heights[i] = height
+		c.tops[i+1] = c.tops[i] + height
+	}
+	c.layoutDirty = false
+}
+
+// remeasure updates the height of the i-th message after it changed
+func (c *ChatView) remeasure(i int) {
+	if i < 0 || i >= len(c.heights) {
+		return
+	}
+	c.setHeight(i, c.messageHeight(i, c.messages[i]))
+}
+
+// setHeight records a new height for the i-th message, moving the
+// messages below it
+func (c *ChatView) setHeight(i, height int) {
+	diff := height - c.heights[i]
+	if diff == 0 {
+		return
+	}
+	c.heights[i] = height
+	for j := i + 1; j < len(c.tops); j++ {
+		c.tops[j] += diff
+	}
+}
+
+// totalHeight returns the lines taken by the messages and any notice
+func (c *ChatView) totalHeight() int {
+	total := c.noticeHeight
+	if n := len(c.tops); n > 0 {
+		total += c.tops[n-1]
+	}
+	return total
+}
+
+// maxOffset returns the offset that shows the end of the conversation
+func (c *ChatView) maxOffset() int {
+	if max := c.totalHeight() - c.viewport.Height; max > 0 {
+		return max
+	}
+	return 0
+}
+
+// atBottom reports whether the end of the conversation is in view
+func (c *ChatView) atBottom() bool {
+	return c.offset >= c.maxOffset()
+}
+
+// scrollTo moves the view to offset and draws what is now visible
+func (c *ChatView) scrollTo(offset int) {
+	c.offset = offset
+	c.renderWindow()
+}
+
+// scrollBy moves the view by lines, up when negative
+func (c *ChatView) scrollBy(lines int) {
+	c.scrollTo(c.offset + lines)
+}
+
+// gotoTop shows the start of the conversation
+func (c *ChatView) gotoTop() {
+	c.scrollTo(0)
+}
+
+// gotoBottom shows the end of the conversation
+func (c *ChatView) gotoBottom() {
+	c.scrollTo(c.maxOffset())
+}
+
+// scrollToFocus moves the view the least needed to show the whole
+// focused message, or its top if it is taller than the view
+func (c *ChatView) scrollToFocus() {
+	if c.focusIndex < 0 || c.focusIndex >= len(c.heights) {
+		return
+	}
+	top := c.tops[c.focusIndex]
+	bottom := top + c.heights[c.focusIndex]
+	if bottom > c.offset+c.viewport.Height {
+		c.offset = bottom - c.viewport.Height
+	}
+	if top < c.offset {
+		c.offset = top
+	}
+}
+
+// renderWindow draws the messages that intersect the visible lines, plus
+// a screen's worth on either side, and positions the viewport in them
+func (c *ChatView) renderWindow() {
+	if c.layoutDirty || len(c.tops) != len(c.messages)+1 {
+		c.measure()
+	}
+	if c.offset > c.maxOffset() {
+		c.offset = c.maxOffset()
+	}
+	if c.offset < 0 {
+		c.offset = 0
+	}
+	c.following = c.offset >= c.maxOffset()
+
+	margin := c.viewport.Height
+	from, to := c.offset-margin, c.offset+c.viewport.Height+margin
+
+	// First message that ends below the start of the window
+	n := len(c.heights)
+	first := sort.Search(n, func(i int) bool {
+		return c.tops[i+1] > from
+	})
+
+	var blocks []string
+	start := c.tops[first]
+	for i := first; i < n && c.tops[i] < to; i++ {
+		block, height := c.renderMessage(i, c.messages[i])
+		c.setHeight(i, height)
+		blocks = append(blocks, block)
+	}
+	if c.notice != nil && c.tops[n] < to {
+		blocks = append(blocks, c.noticeBlock)
+	}
+
+	c.viewport.SetContent(strings.Join(blocks, "\n"))
+	c.viewport.SetYOffset(c.offset - start)
+}
@@ -1,31 +1,148 @@
 package ui
 
 import (
+	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/saiashirwad/gochat/internal/config"
+	"github.com/saiashirwad/gochat/internal/theme"
 )
 
+// Styles are built from the current theme by applyTheme. The chat view
+// sets their widths as the window is resized.
 var (
-	// Common styles
-	appStyle = lipgloss.NewStyle().
-		Margin(1, 2)
+	// Theme the styles were built from
+	currentTheme *theme.Theme
 
-	// Message styles
-	userMsgStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("86")).
-		Bold(true)
+	// Style for the entire chat area
+	chatStyle lipgloss.Style
+
+	// Message styles - a left border colored by role, or yellow when
+	// focused
+	baseMessageStyle    lipgloss.Style
+	userMessageStyle    lipgloss.Style
+	llmMessageStyle     lipgloss.Style
+	systemMessageStyle  lipgloss.Style
+	focusedMessageStyle lipgloss.Style
+
+	// Style for error notices - red indicator and text
+	errorMessageStyle lipgloss.Style
 
-	botMsgStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("170"))
+	// Style for reasoning sections - dimmed so answers stand out
+	reasoningStyle lipgloss.Style
+
+	// Header styles - subtle emphasis, no margins, with the details
+	// after the label dimmed
+	headerStyle     lipgloss.Style
+	headerInfoStyle lipgloss.Style
+
+	// Status line style
+	statusStyle lipgloss.Style
 
 	// Input styles
-	inputPromptStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("63")).
-		Bold(true)
+	inputTextStyle   lipgloss.Style
+	placeholderStyle lipgloss.Style
+
+	// Command popup styles
+	popupStyle         lipgloss.Style
+	popupSelectedStyle lipgloss.Style
+	popupDescStyle     lipgloss.Style
+
+	// Finder styles: the box, matched characters in results, the
+	// selected result, and dates and snippets
+	finderBoxStyle    lipgloss.Style
+	finderMatchStyle  lipgloss.Style
+	finderCursorStyle lipgloss.Style
+	finderDimStyle    lipgloss.Style
+
+	// Markdown renderer for message bodies
+	markdownRenderer *glamour.TermRenderer
+)
+
+func init() {
+	applyTheme(theme.Builtin("dark"))
+	markdownRenderer, _ = currentTheme.Renderer(0) // Wrap is set once the size is known
+}
 
-	// Title styles
-	titleStyle = lipgloss.NewStyle().
-		Background(lipgloss.Color("62")).
-		Foreground(lipgloss.Color("230")).
-		Padding(0, 1).
+// useTheme switches to the theme named in the config. A theme that cannot
+// be loaded leaves the current one in place.
+func useTheme(cfg *config.Config) error {
+	t, err := theme.Load(cfg.UI.Theme, cfg.ThemeDirs())
+	if err != nil {
+		return err
+	}
+	applyTheme(t)
+	return nil
+}
+
+// applyTheme rebuilds every style from t. Widths are reset, so views must
+// be resized afterwards.
+func applyTheme(t *theme.Theme) {
+	currentTheme = t
+
+	chatStyle = lipgloss.NewStyle()
+
+	baseMessageStyle = lipgloss.NewStyle().
+		PaddingLeft(1).
+		PaddingRight(1).
+		BorderLeft(true).
+		BorderStyle(t.BorderStyle()).
+		MarginTop(-1) // Remove gap between messages
+	userMessageStyle = baseMessageStyle.Copy().
+		BorderLeftForeground(t.Roles.User)
+	llmMessageStyle = baseMessageStyle.Copy().
+		BorderLeftForeground(t.Roles.Assistant)
+	systemMessageStyle = baseMessageStyle.Copy().
+		BorderLeftForeground(t.Roles.System)
+	focusedMessageStyle = baseMessageStyle.Copy().
+		BorderLeftForeground(t.Roles.Focused)
+	errorMessageStyle = baseMessageStyle.Copy().
+		BorderLeftForeground(t.Roles.Error).
+		Foreground(t.Error)
+
+	reasoningStyle = lipgloss.NewStyle().
+		Foreground(t.Reasoning).
+		Italic(true)
+
+	headerStyle = lipgloss.NewStyle().
+		Foreground(t.Header).
+		Bold(true).
+		PaddingLeft(1).
+		PaddingRight(1).
+		MarginBottom(0).
+		Height(1) // Force single line height
+	headerInfoStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		Bold(false)
+
+	statusStyle = lipgloss.NewStyle().
+		Foreground(t.Status).
+		Italic(true).
+		PaddingLeft(1)
+
+	inputTextStyle = lipgloss.NewStyle().
+		Foreground(t.Input)
+	placeholderStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	popupStyle = lipgloss.NewStyle().
+		Foreground(t.Text).
+		PaddingLeft(1)
+	popupSelectedStyle = popupStyle.Copy().
+		Background(t.Selection).
+		Foreground(t.SelectionText)
+	popupDescStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	finderBoxStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(t.Accent).
+		Padding(1)
+	finderMatchStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight).
+		Bold(true)
+	finderCursorStyle = lipgloss.NewStyle().
+		Foreground(t.Accent).
 		Bold(true)
-) 
\ No newline at end of file
+	finderDimStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+}